@@ -1,21 +1,89 @@
 package main
 
 import (
+	"github.com/sema/cadencecheck/pkg/entities"
+	"github.com/sema/cadencecheck/pkg/reporter"
 	"github.com/sema/cadencecheck/pkg/runner"
 	"gopkg.in/alecthomas/kingpin.v2"
 	"log"
 	"os"
+	"strings"
 )
 
 var (
-	pkgName = kingpin.Arg("package", "Go package to check").Required().String()
 	verbose = kingpin.Flag("verbose", "print debug information").Bool()
+
+	panicSeverity = kingpin.Flag("panic-severity", "severity of panic and recover in workflows").
+			Default("error").Enum("error", "warning", "ignore")
+
+	callGraph = kingpin.Flag("callgraph", "call graph algorithm, trading precision for speed; pointer analysis "+
+		"is not available, as golang.org/x/tools no longer ships it").
+		Default(string(runner.CallGraphRTA)).Enum(callGraphAlgorithms()...)
+
+	newestVersionOnly = kingpin.Flag("newest-version-only", "only report issues in the newest GetVersion branches").Bool()
+	reportUnused      = kingpin.Flag("report-unused", "report workflows and activities never executed by the package").Bool()
+	heavyFunctions    = kingpin.Flag("heavy-function", "additional function to report when called from a workflow, "+
+		"e.g. crypto/sha256.Sum256, encoding/json.Encoder.Encode or gopkg.in/yaml.v2:Marshal").Strings()
+
+	checkCmd = kingpin.Command("check", "check workflows and activities of a package").Default()
+	pkgName  = checkCmd.Arg("package", "Go package to check").Required().String()
+
+	diffCmd     = kingpin.Command("diff", "check changes to workflows of a package for breaking replay")
+	diffPkgName = diffCmd.Arg("package", "Go package to check").Required().String()
+	diffBase    = diffCmd.Flag("base", "git revision of the repository, or directory holding a copy of the package, to diff against").
+			Required().String()
+
+	modelCmd     = kingpin.Command("model", "print the commands each workflow of a package can issue")
+	modelPkgName = modelCmd.Arg("package", "Go package to model").Required().String()
+	modelFormat  = modelCmd.Flag("format", "output format").
+			Default(runner.ModelFormatDOT).Enum(runner.ModelFormatDOT, runner.ModelFormatJSON)
+
+	topologyCmd     = kingpin.Command("topology", "print the workers, workflows and activities of a package as a graph")
+	topologyPkgName = topologyCmd.Arg("package", "Go package to map").Required().String()
+	topologyFormat  = topologyCmd.Flag("format", "output format").Default(runner.TopologyFormatDOT).
+			Enum(runner.TopologyFormatDOT, runner.TopologyFormatMermaid, runner.TopologyFormatJSON)
 )
 
+func callGraphAlgorithms() []string {
+	var result []string
+	for _, algorithm := range runner.CallGraphAlgorithms {
+		result = append(result, string(algorithm))
+	}
+	return result
+}
+
 func main() {
-	kingpin.Parse()
+	command := kingpin.Parse()
+
+	var heavyFunctionPatterns []entities.FunctionPattern
+	for _, f := range *heavyFunctions {
+		pattern, err := entities.ParseFunctionPattern(f)
+		if err != nil {
+			log.Fatalf("Error %s", err)
+		}
+		heavyFunctionPatterns = append(heavyFunctionPatterns, pattern)
+	}
 
-	err := runner.Run(*pkgName, os.Stdout, os.Stderr, *verbose)
+	config := runner.Config{
+		Verbose:           *verbose,
+		PanicSeverity:     reporter.Severity(strings.ToUpper(*panicSeverity)),
+		NewestVersionOnly: *newestVersionOnly,
+		ReportUnused:      *reportUnused,
+		HeavyFunctions:    heavyFunctionPatterns,
+		CallGraph:         runner.CallGraphAlgorithm(*callGraph),
+	}
+
+	var err error
+	switch command {
+	case diffCmd.FullCommand():
+		err = runner.RunDiff(*diffPkgName, *diffBase, os.Stdout, os.Stderr, config)
+	case modelCmd.FullCommand():
+		err = runner.RunModel(*modelPkgName, *modelFormat, os.Stdout, os.Stderr, config)
+	case topologyCmd.FullCommand():
+		err = runner.RunTopology(*topologyPkgName, *topologyFormat, os.Stdout, os.Stderr, config)
+	default:
+		err = runner.Run(*pkgName, os.Stdout, os.Stderr, config)
+	}
 	if err != nil {
 		log.Fatalf("Error %s", err)
 	}
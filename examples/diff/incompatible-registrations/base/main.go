@@ -0,0 +1,45 @@
+package main
+
+import (
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/workflow"
+)
+
+func charge(amount int) error {
+	return nil
+}
+
+func refund(amount int) error {
+	return nil
+}
+
+func notify(email string) error {
+	return nil
+}
+
+func discount(rate float64) error {
+	return nil
+}
+
+func tip(amount int) error {
+	return nil
+}
+
+func orderWorkflow(ctx workflow.Context, amount int) error {
+	return workflow.ExecuteActivity(ctx, charge, amount).Get(ctx, nil)
+}
+
+func refundWorkflow(ctx workflow.Context, reason string, amount int) error {
+	return workflow.ExecuteActivity(ctx, refund, amount).Get(ctx, nil)
+}
+
+func main() {
+	workflow.RegisterWithOptions(orderWorkflow, workflow.RegisterOptions{Name: "order"})
+	workflow.Register(refundWorkflow)
+	activity.Register(charge)
+	activity.Register(notify)
+	activity.Register(discount)
+	activity.Register(tip)
+	activity.Register(refund)
+	return
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/workflow"
+)
+
+func charge(amount int64) error {
+	return nil
+}
+
+func notify(emails []string) error {
+	return nil
+}
+
+func discount(rate int) error {
+	return nil
+}
+
+func tip(amount float64) error {
+	return nil
+}
+
+func orderWorkflow(ctx workflow.Context, amount int) error {
+	return workflow.ExecuteActivity(ctx, charge, amount).Get(ctx, nil)
+}
+
+func cancelWorkflow(ctx workflow.Context, reason string, amount int) error {
+	return nil
+}
+
+func main() {
+	workflow.RegisterWithOptions(orderWorkflow, workflow.RegisterOptions{Name: "order-v2"})
+	workflow.Register(cancelWorkflow)
+	activity.Register(charge)
+	activity.Register(notify)
+	activity.Register(discount)
+	activity.Register(tip)
+	return
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/workflow"
+)
+
+func Ship(orderID string) error {
+	return nil
+}
+
+func ShipWorkflow(ctx workflow.Context, orderID string) error {
+	return workflow.ExecuteActivity(ctx, Ship, orderID).Get(ctx, nil)
+}
+
+func orderWorkflow(ctx workflow.Context, orderID string) error {
+	return workflow.ExecuteChildWorkflow(ctx, ShipWorkflow, orderID).Get(ctx, nil)
+}
+
+func main() {
+	workflow.Register(orderWorkflow)
+	workflow.Register(ShipWorkflow)
+	activity.Register(Ship)
+	return
+}
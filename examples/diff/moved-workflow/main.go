@@ -0,0 +1,18 @@
+package main
+
+import (
+	"github.com/sema/cadencecheck/examples/diff/moved-workflow/shipping"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/workflow"
+)
+
+func orderWorkflow(ctx workflow.Context, orderID string) error {
+	return workflow.ExecuteChildWorkflow(ctx, shipping.ShipWorkflow, orderID).Get(ctx, nil)
+}
+
+func main() {
+	workflow.Register(orderWorkflow)
+	workflow.Register(shipping.ShipWorkflow)
+	activity.Register(shipping.Ship)
+	return
+}
@@ -0,0 +1,13 @@
+package shipping
+
+import (
+	"go.uber.org/cadence/workflow"
+)
+
+func Ship(orderID string) error {
+	return nil
+}
+
+func ShipWorkflow(ctx workflow.Context, orderID string) error {
+	return workflow.ExecuteActivity(ctx, Ship, orderID).Get(ctx, nil)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/workflow"
+)
+
+func reserveActivity() error {
+	return nil
+}
+
+func releaseActivity() error {
+	return nil
+}
+
+func notifyActivity() error {
+	return nil
+}
+
+// reserve and release call each other, so the commands of release depend on whether it is reached through reserve
+func reserve(ctx workflow.Context, attempt int) error {
+	if err := workflow.ExecuteActivity(ctx, reserveActivity).Get(ctx, nil); err != nil {
+		return release(ctx, attempt)
+	}
+	return workflow.ExecuteActivity(ctx, notifyActivity).Get(ctx, nil)
+}
+
+func release(ctx workflow.Context, attempt int) error {
+	if err := workflow.ExecuteActivity(ctx, releaseActivity).Get(ctx, nil); err != nil {
+		return err
+	}
+	if attempt < 3 {
+		return reserve(ctx, attempt+1)
+	}
+	return nil
+}
+
+func bookingWorkflow(ctx workflow.Context) error {
+	if err := reserve(ctx, 0); err != nil {
+		return err
+	}
+	return release(ctx, 0)
+}
+
+func main() {
+	workflow.Register(bookingWorkflow)
+	activity.Register(reserveActivity)
+	activity.Register(releaseActivity)
+	activity.Register(notifyActivity)
+	return
+}
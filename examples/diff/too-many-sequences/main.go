@@ -0,0 +1,90 @@
+package main
+
+import (
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/workflow"
+	"time"
+)
+
+func step0() error {
+	return nil
+}
+
+func step1() error {
+	return nil
+}
+
+func step2() error {
+	return nil
+}
+
+func step3() error {
+	return nil
+}
+
+func step4() error {
+	return nil
+}
+
+func step5() error {
+	return nil
+}
+
+func step6() error {
+	return nil
+}
+
+// setupWorkflow runs any combination of seven optional steps, producing more command sequences than are compared
+func setupWorkflow(ctx workflow.Context, options int) error {
+	if err := workflow.Sleep(ctx, time.Minute); err != nil {
+		return err
+	}
+	if options&1 != 0 {
+		if err := workflow.ExecuteActivity(ctx, step0).Get(ctx, nil); err != nil {
+			return err
+		}
+	}
+	if options&2 != 0 {
+		if err := workflow.ExecuteActivity(ctx, step1).Get(ctx, nil); err != nil {
+			return err
+		}
+	}
+	if options&4 != 0 {
+		if err := workflow.ExecuteActivity(ctx, step2).Get(ctx, nil); err != nil {
+			return err
+		}
+	}
+	if options&8 != 0 {
+		if err := workflow.ExecuteActivity(ctx, step3).Get(ctx, nil); err != nil {
+			return err
+		}
+	}
+	if options&16 != 0 {
+		if err := workflow.ExecuteActivity(ctx, step4).Get(ctx, nil); err != nil {
+			return err
+		}
+	}
+	if options&32 != 0 {
+		if err := workflow.ExecuteActivity(ctx, step5).Get(ctx, nil); err != nil {
+			return err
+		}
+	}
+	if options&64 != 0 {
+		if err := workflow.ExecuteActivity(ctx, step6).Get(ctx, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func main() {
+	workflow.Register(setupWorkflow)
+	activity.Register(step0)
+	activity.Register(step1)
+	activity.Register(step2)
+	activity.Register(step3)
+	activity.Register(step4)
+	activity.Register(step5)
+	activity.Register(step6)
+	return
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/workflow"
+	"time"
+)
+
+func charge(amount int) error {
+	return nil
+}
+
+func notify(amount int) error {
+	return nil
+}
+
+func workflowImpl(ctx workflow.Context, amount int) error {
+	if err := workflow.ExecuteActivity(ctx, charge, amount).Get(ctx, nil); err != nil {
+		return err
+	}
+
+	if amount > 100 {
+		return workflow.Sleep(ctx, time.Hour)
+	}
+	return nil
+}
+
+func refundWorkflow(ctx workflow.Context, amount int) error {
+	return workflow.ExecuteActivity(ctx, charge, -amount).Get(ctx, nil)
+}
+
+func main() {
+	workflow.Register(workflowImpl)
+	workflow.Register(refundWorkflow)
+	activity.Register(charge)
+	activity.Register(notify)
+	return
+}
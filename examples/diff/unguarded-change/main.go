@@ -0,0 +1,37 @@
+package main
+
+import (
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/workflow"
+	"time"
+)
+
+func charge(amount int) error {
+	return nil
+}
+
+func notify(amount int) error {
+	return nil
+}
+
+func workflowImpl(ctx workflow.Context, amount int) error {
+	if err := workflow.ExecuteActivity(ctx, charge, amount).Get(ctx, nil); err != nil {
+		return err
+	}
+
+	if err := workflow.ExecuteActivity(ctx, notify, amount).Get(ctx, nil); err != nil {
+		return err
+	}
+
+	if amount > 100 {
+		return workflow.Sleep(ctx, time.Hour)
+	}
+	return nil
+}
+
+func main() {
+	workflow.Register(workflowImpl)
+	activity.Register(charge)
+	activity.Register(notify)
+	return
+}
@@ -3,13 +3,16 @@ package examples
 import (
 	"bufio"
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"github.com/sema/cadencecheck/pkg/runner"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"io"
 	"io/ioutil"
 	"log"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"regexp"
 	"testing"
@@ -18,12 +21,31 @@ import (
 const (
 	_goldenTestOutputFilename = "test-output.golden"
 	_goldenTestMainFilename   = "main.go"
+	_goldenTestConfigFilename = "config.json"
 	_packageTemplate          = "github.com/sema/cadencecheck/examples/%s"
+	_diffExamplesDir          = "diff"
+	_diffBaseDir              = "base"
+	_modelExamplesDir         = "model"
+	_modelGoldenTemplate      = "model.%s.golden"
+	_topologyExamplesDir      = "topology"
+	_topologyGoldenTemplate   = "topology.%s.golden"
+	_revisionExampleDir       = "testdata/revision"
+	_revisionHeadDir          = "head"
+	_revisionWorkspaceDir     = "testdata/workspace"
 	_goldenFileUpdateFlag     = "UPDATE_GOLDEN"
 )
 
 func TestExamplesAndCompareAgainstGoldenOutput(t *testing.T) {
 	err := filepath.Walk(".", func(path string, info os.FileInfo, err error) error {
+		if info.IsDir() && path == _diffExamplesDir {
+			return filepath.SkipDir // checked by TestDiffExamplesAndCompareAgainstGoldenOutput
+		}
+		if info.IsDir() && path == _modelExamplesDir {
+			return filepath.SkipDir // checked by TestModelExamplesAndCompareAgainstGoldenOutput
+		}
+		if info.IsDir() && path == _topologyExamplesDir {
+			return filepath.SkipDir // checked by TestTopologyExamplesAndCompareAgainstGoldenOutput
+		}
 		if info.Name() != _goldenTestMainFilename {
 			return nil
 		}
@@ -32,33 +54,175 @@ func TestExamplesAndCompareAgainstGoldenOutput(t *testing.T) {
 		testPkg := fmt.Sprintf(_packageTemplate, testDir)
 
 		goldenFilePath := filepath.Join(testDir, _goldenTestOutputFilename)
+		config := readConfig(t, filepath.Join(testDir, _goldenTestConfigFilename))
 
 		t.Run(testDir, func(t *testing.T) {
-			t.Parallel()
-
-			var outputBuffer bytes.Buffer
-			outputWriter := bufio.NewWriter(&outputBuffer)
+			runGoldenTest(t, goldenFilePath, func(w io.Writer) error {
+				return runner.Run(testPkg, w, w, config)
+			})
+		})
 
-			err = runner.Run(testPkg, outputWriter, outputWriter, false)
-			require.NoError(t, err)
+		return nil
+	})
+	require.NoError(t, err)
+}
 
-			err = outputWriter.Flush() // force io.Writer to write to the buffer
-			require.NoError(t, err)
+// TestDiffExamplesAndCompareAgainstGoldenOutput runs the diff of each example in the diff directory against the base
+// revision in its base directory
+func TestDiffExamplesAndCompareAgainstGoldenOutput(t *testing.T) {
+	examples, err := ioutil.ReadDir(_diffExamplesDir)
+	require.NoError(t, err)
 
-			actualOutput := normalizeOutput(outputBuffer.Bytes())
+	for _, example := range examples {
+		testDir := filepath.Join(_diffExamplesDir, example.Name())
+		testPkg := fmt.Sprintf(_packageTemplate, testDir)
 
-			if os.Getenv(_goldenFileUpdateFlag) != "" {
-				updateGoldenFile(t, goldenFilePath, actualOutput)
-			}
+		goldenFilePath := filepath.Join(testDir, _goldenTestOutputFilename)
 
-			assertGoldenFile(t, goldenFilePath, actualOutput)
+		t.Run(testDir, func(t *testing.T) {
+			runGoldenTest(t, goldenFilePath, func(w io.Writer) error {
+				return runner.RunDiff(testPkg, filepath.Join(testDir, _diffBaseDir), w, w, runner.Config{})
+			})
 		})
+	}
+}
 
-		return nil
+// TestDiffAgainstGitRevision diffs a package against the git revision holding its base version, which differs in a
+// package imported by the workflow. The package is copied to a workspace directory within this repository, so its
+// vendored dependencies resolve, and committed to a new git repository.
+func TestDiffAgainstGitRevision(t *testing.T) {
+	require.NoError(t, os.RemoveAll(_revisionWorkspaceDir))
+	defer os.RemoveAll(_revisionWorkspaceDir)
+
+	copyDir(t, filepath.Join(_revisionExampleDir, _diffBaseDir), _revisionWorkspaceDir)
+	runGit(t, "init", "-q")
+	runGit(t, "add", "-A")
+	runGit(t, "-c", "user.name=cadencecheck", "-c", "user.email=cadencecheck@example.com", "commit", "-q", "-m", "base")
+	copyDir(t, filepath.Join(_revisionExampleDir, _revisionHeadDir), _revisionWorkspaceDir)
+
+	testPkg := fmt.Sprintf(_packageTemplate, _revisionWorkspaceDir)
+	goldenFilePath := filepath.Join(_revisionExampleDir, _goldenTestOutputFilename)
+
+	runGoldenTest(t, goldenFilePath, func(w io.Writer) error {
+		return runner.RunDiff(testPkg, "HEAD", w, w, runner.Config{})
+	})
+}
+
+// TestModelExamplesAndCompareAgainstGoldenOutput exports the command model of each example in the model directory in
+// all formats
+func TestModelExamplesAndCompareAgainstGoldenOutput(t *testing.T) {
+	examples, err := ioutil.ReadDir(_modelExamplesDir)
+	require.NoError(t, err)
+
+	for _, example := range examples {
+		testDir := filepath.Join(_modelExamplesDir, example.Name())
+		testPkg := fmt.Sprintf(_packageTemplate, testDir)
+
+		for _, format := range []string{runner.ModelFormatDOT, runner.ModelFormatJSON} {
+			goldenFilePath := filepath.Join(testDir, fmt.Sprintf(_modelGoldenTemplate, format))
+			format := format
+
+			t.Run(filepath.Join(testDir, format), func(t *testing.T) {
+				runGoldenTest(t, goldenFilePath, func(w io.Writer) error {
+					return runner.RunModel(testPkg, format, w, w, runner.Config{})
+				})
+			})
+		}
+	}
+}
+
+// TestTopologyExamplesAndCompareAgainstGoldenOutput exports the topology of each example in the topology directory
+// in all formats
+func TestTopologyExamplesAndCompareAgainstGoldenOutput(t *testing.T) {
+	examples, err := ioutil.ReadDir(_topologyExamplesDir)
+	require.NoError(t, err)
+
+	for _, example := range examples {
+		testDir := filepath.Join(_topologyExamplesDir, example.Name())
+		testPkg := fmt.Sprintf(_packageTemplate, testDir)
+
+		for _, format := range []string{runner.TopologyFormatDOT, runner.TopologyFormatMermaid, runner.TopologyFormatJSON} {
+			goldenFilePath := filepath.Join(testDir, fmt.Sprintf(_topologyGoldenTemplate, format))
+			format := format
+
+			t.Run(filepath.Join(testDir, format), func(t *testing.T) {
+				runGoldenTest(t, goldenFilePath, func(w io.Writer) error {
+					return runner.RunTopology(testPkg, format, w, w, runner.Config{})
+				})
+			})
+		}
+	}
+}
+
+// copyDir copies the files of the source directory into the target directory, overwriting existing files
+func copyDir(t *testing.T, sourceDir string, targetDir string) {
+	err := filepath.Walk(sourceDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(sourceDir, path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return os.MkdirAll(filepath.Join(targetDir, rel), os.ModePerm)
+		}
+
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(filepath.Join(targetDir, rel), content, os.ModePerm)
 	})
 	require.NoError(t, err)
 }
 
+// runGit runs a git command in the workspace directory of TestDiffAgainstGitRevision
+func runGit(t *testing.T, args ...string) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = _revisionWorkspaceDir
+
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(output))
+}
+
+// readConfig reads the runner configuration of an example, which defaults to the zero value if there is no config file
+func readConfig(t *testing.T, configPath string) runner.Config {
+	var config runner.Config
+
+	content, err := ioutil.ReadFile(configPath)
+	if os.IsNotExist(err) {
+		return config
+	}
+	require.NoError(t, err)
+
+	require.NoError(t, json.Unmarshal(content, &config))
+	return config
+}
+
+// runGoldenTest compares the output written by run against a golden file, or updates the golden file if requested
+func runGoldenTest(t *testing.T, goldenFilePath string, run func(w io.Writer) error) {
+	t.Parallel()
+
+	var outputBuffer bytes.Buffer
+	outputWriter := bufio.NewWriter(&outputBuffer)
+
+	err := run(outputWriter)
+	require.NoError(t, err)
+
+	err = outputWriter.Flush() // force io.Writer to write to the buffer
+	require.NoError(t, err)
+
+	actualOutput := normalizeOutput(outputBuffer.Bytes())
+
+	if os.Getenv(_goldenFileUpdateFlag) != "" {
+		updateGoldenFile(t, goldenFilePath, actualOutput)
+	}
+
+	assertGoldenFile(t, goldenFilePath, actualOutput)
+}
+
 // normalizeOutput replaces parts of the output to make it stable across different environments (e.g. strips file paths)
 func normalizeOutput(actualOutput []byte) []byte {
 	r, err := regexp.Compile("[a-zA-Z0-9_\\-/.]+/src/")
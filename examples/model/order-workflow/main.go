@@ -0,0 +1,57 @@
+package main
+
+import (
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/workflow"
+	"time"
+)
+
+func reserve(item string) error {
+	return nil
+}
+
+func charge(item string) error {
+	return nil
+}
+
+func notify(item string) error {
+	return nil
+}
+
+func status() (string, error) {
+	return "pending", nil
+}
+
+func chargeWithRetries(ctx workflow.Context, item string) error {
+	for i := 0; i < 3; i++ {
+		if err := workflow.ExecuteActivity(ctx, charge, item).Get(ctx, nil); err == nil {
+			return nil
+		}
+		workflow.Sleep(ctx, time.Minute)
+	}
+	return workflow.ExecuteActivity(ctx, notify, item).Get(ctx, nil)
+}
+
+func orderWorkflow(ctx workflow.Context, item string) error {
+	workflow.SetQueryHandler(ctx, "status", status)
+
+	if err := workflow.ExecuteActivity(ctx, reserve, item).Get(ctx, nil); err != nil {
+		return err
+	}
+
+	var cancelled bool
+	workflow.GetSignalChannel(ctx, "cancel").ReceiveAsync(&cancelled)
+	if cancelled {
+		return nil
+	}
+
+	return chargeWithRetries(ctx, item)
+}
+
+func main() {
+	workflow.Register(orderWorkflow)
+	activity.Register(reserve)
+	activity.Register(charge)
+	activity.Register(notify)
+	return
+}
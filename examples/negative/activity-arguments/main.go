@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/workflow"
+	"time"
+)
+
+type request struct {
+	Name string
+}
+
+func activityImpl(ctx context.Context, r *request, count int) (string, error) {
+	return r.Name, nil
+}
+
+func childWorkflowImpl(ctx workflow.Context, names ...string) error {
+	return nil
+}
+
+func workflowImpl(ctx workflow.Context) error {
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		ScheduleToStartTimeout: time.Minute,
+		StartToCloseTimeout:    time.Minute,
+	})
+
+	var name string
+	if err := workflow.ExecuteActivity(ctx, activityImpl, &request{Name: "name"}, 1).Get(ctx, &name); err != nil {
+		return err
+	}
+	if err := workflow.ExecuteActivity(ctx, activityImpl, nil, 2).Get(ctx, nil); err != nil {
+		return err
+	}
+
+	ctx = workflow.WithChildOptions(ctx, workflow.ChildWorkflowOptions{
+		ExecutionStartToCloseTimeout: time.Hour,
+	})
+	return workflow.ExecuteChildWorkflow(ctx, childWorkflowImpl, "a", "b").Get(ctx, nil)
+}
+
+func main() {
+	workflow.Register(workflowImpl)
+	workflow.Register(childWorkflowImpl)
+	activity.Register(activityImpl)
+	return
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/workflow"
+	"time"
+)
+
+func activityImpl(ctx context.Context) error {
+	return nil
+}
+
+func withOptions(ctx workflow.Context) workflow.Context {
+	return workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		ScheduleToStartTimeout: time.Minute,
+		StartToCloseTimeout:    time.Minute,
+	})
+}
+
+func schedule(ctx workflow.Context) error {
+	return workflow.ExecuteActivity(ctx, activityImpl).Get(ctx, nil)
+}
+
+func workflowImpl(ctx workflow.Context) error {
+	ctx = withOptions(ctx)
+	ctx = workflow.WithValue(ctx, "key", "value")
+
+	var err error
+	workflow.Go(ctx, func(ctx workflow.Context) {
+		err = schedule(ctx)
+	})
+
+	if err := schedule(ctx); err != nil {
+		return err
+	}
+
+	// ctx is captured by reference, only the configured value reaches the loads below
+	selector := workflow.NewSelector(ctx)
+	selector.AddFuture(workflow.NewTimer(ctx, time.Minute), func(f workflow.Future) {
+		err = f.Get(ctx, nil)
+	})
+	selector.Select(ctx)
+
+	if err := schedule(ctx); err != nil {
+		return err
+	}
+	return err
+}
+
+func main() {
+	workflow.Register(workflowImpl)
+	activity.Register(activityImpl)
+	return
+}
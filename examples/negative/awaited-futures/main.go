@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/workflow"
+	"time"
+)
+
+func activityImpl(ctx context.Context) error {
+	return nil
+}
+
+func childWorkflowImpl(ctx workflow.Context) error {
+	return nil
+}
+
+func startActivity(ctx workflow.Context) workflow.Future {
+	return workflow.ExecuteActivity(ctx, activityImpl)
+}
+
+func workflowImpl(ctx workflow.Context) error {
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		ScheduleToStartTimeout: time.Minute,
+		StartToCloseTimeout:    time.Minute,
+	})
+
+	if err := workflow.ExecuteActivity(ctx, activityImpl).Get(ctx, nil); err != nil {
+		return err
+	}
+
+	var futures []workflow.Future
+	for i := 0; i < 3; i++ {
+		futures = append(futures, startActivity(ctx))
+	}
+	for _, future := range futures {
+		if err := future.Get(ctx, nil); err != nil {
+			return err
+		}
+	}
+
+	var timerErr error
+	selector := workflow.NewSelector(ctx)
+	selector.AddFuture(workflow.NewTimer(ctx, time.Minute), func(f workflow.Future) {
+		timerErr = f.Get(ctx, nil)
+	})
+	selector.Select(ctx)
+	if timerErr != nil {
+		return timerErr
+	}
+
+	ctx = workflow.WithChildOptions(ctx, workflow.ChildWorkflowOptions{
+		ExecutionStartToCloseTimeout: time.Hour,
+	})
+	child := workflow.ExecuteChildWorkflow(ctx, childWorkflowImpl)
+	if err := child.GetChildWorkflowExecution().Get(ctx, nil); err != nil {
+		return err
+	}
+	if err := child.Get(ctx, nil); err != nil {
+		return err
+	}
+
+	// waiting for the child workflow to start is enough to learn whether it could be started
+	return workflow.ExecuteChildWorkflow(ctx, childWorkflowImpl).GetChildWorkflowExecution().Get(ctx, nil)
+}
+
+func main() {
+	workflow.Register(workflowImpl)
+	workflow.Register(childWorkflowImpl)
+	activity.Register(activityImpl)
+	return
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"go.uber.org/cadence/workflow"
+)
+
+func receiveSignal(ctx workflow.Context, c workflow.Channel) string {
+	var signal string
+	c.Receive(ctx, &signal)
+	return signal
+}
+
+func workflowImpl(ctx workflow.Context) error {
+	signals := workflow.GetSignalChannel(ctx, "signal")
+	cancellations := workflow.GetSignalChannel(ctx, "cancel")
+	updates := workflow.GetSignalChannel(ctx, "update")
+
+	var received []string
+	cancelled := false
+
+	selector := workflow.NewSelector(ctx)
+	selector.AddReceive(signals, func(c workflow.Channel, more bool) {
+		received = append(received, receiveSignal(ctx, c))
+	})
+	selector.AddReceive(cancellations, func(c workflow.Channel, more bool) {
+		cancellations.ReceiveAsync(nil)
+		cancelled = true
+	})
+	selector.AddReceive(updates, func(c workflow.Channel, more bool) {
+		var update string
+		for c.ReceiveAsync(&update) {
+			received = append(received, update)
+		}
+	})
+
+	for !cancelled {
+		selector.Select(ctx)
+	}
+	return nil
+}
+
+func main() {
+	workflow.Register(workflowImpl)
+	return
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"go.uber.org/cadence/workflow"
+	"time"
+)
+
+type state struct {
+	ctx     workflow.Context
+	pending []workflow.Context
+}
+
+func newState(ctx workflow.Context) *state {
+	return &state{ctx: ctx}
+}
+
+func (s *state) add(ctx workflow.Context) {
+	s.pending = append(s.pending, ctx)
+}
+
+func (s *state) wait() error {
+	return workflow.Sleep(s.ctx, time.Minute)
+}
+
+func workflowImpl(ctx workflow.Context) error {
+	s := newState(ctx)
+	s.add(workflow.WithValue(ctx, "key", "value"))
+
+	workflow.Go(ctx, func(ctx workflow.Context) {
+		s.add(ctx)
+	})
+
+	return s.wait()
+}
+
+func main() {
+	workflow.Register(workflowImpl)
+	return
+}
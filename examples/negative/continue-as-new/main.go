@@ -0,0 +1,87 @@
+package main
+
+import (
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/workflow"
+	"time"
+)
+
+const _maxIterations = 1000
+
+func poll() error {
+	return nil
+}
+
+func process(item string) error {
+	return nil
+}
+
+func withOptions(ctx workflow.Context) workflow.Context {
+	return workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		ScheduleToStartTimeout: time.Minute,
+		StartToCloseTimeout:    time.Minute,
+	})
+}
+
+func pollingWorkflow(ctx workflow.Context) error {
+	ctx = withOptions(ctx)
+	for i := 0; ; i++ {
+		if i >= _maxIterations {
+			return workflow.NewContinueAsNewError(ctx, pollingWorkflow)
+		}
+		if err := workflow.ExecuteActivity(ctx, poll).Get(ctx, nil); err != nil {
+			return err
+		}
+		if err := workflow.Sleep(ctx, time.Minute); err != nil {
+			return err
+		}
+	}
+}
+
+func continueAsNew(ctx workflow.Context) error {
+	return workflow.NewContinueAsNewError(ctx, queueWorkflow)
+}
+
+func queueWorkflow(ctx workflow.Context) error {
+	ctx = withOptions(ctx)
+	items := workflow.GetSignalChannel(ctx, "item")
+
+	processed := 0
+	for {
+		var item string
+		items.Receive(ctx, &item)
+		if err := workflow.ExecuteActivity(ctx, process, item).Get(ctx, nil); err != nil {
+			return err
+		}
+
+		processed++
+		if processed > _maxIterations {
+			break
+		}
+	}
+	return continueAsNew(ctx)
+}
+
+func batchWorkflow(ctx workflow.Context, items []string) error {
+	ctx = withOptions(ctx)
+	for _, item := range items {
+		if err := workflow.ExecuteActivity(ctx, process, item).Get(ctx, nil); err != nil {
+			return err
+		}
+	}
+	for attempt := 0; attempt < 3; attempt++ {
+		if err := workflow.ExecuteActivity(ctx, poll).Get(ctx, nil); err == nil {
+			return nil
+		}
+	}
+	return nil
+}
+
+func main() {
+	workflow.Register(pollingWorkflow)
+	workflow.Register(queueWorkflow)
+	workflow.Register(batchWorkflow)
+	activity.Register(poll)
+	activity.Register(process)
+	return
+}
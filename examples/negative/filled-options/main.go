@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/workflow"
+	"time"
+)
+
+func activityImpl(ctx context.Context) error {
+	return nil
+}
+
+func childWorkflowImpl(ctx workflow.Context) error {
+	return nil
+}
+
+// setTimeouts fills in the timeouts missing from the composite literals below, which can't be validated on their own
+func setTimeouts(options *workflow.ActivityOptions) {
+	options.ScheduleToStartTimeout = time.Minute
+	options.StartToCloseTimeout = time.Minute
+	options.ScheduleToCloseTimeout = 2 * time.Minute
+}
+
+func setTimeout(timeout *time.Duration) {
+	*timeout = time.Hour
+}
+
+func workflowImpl(ctx workflow.Context) error {
+	options := workflow.ActivityOptions{
+		TaskList: "orders",
+	}
+	setTimeouts(&options)
+
+	activityCtx := workflow.WithActivityOptions(ctx, options)
+	if err := workflow.ExecuteActivity(activityCtx, activityImpl).Get(ctx, nil); err != nil {
+		return err
+	}
+
+	childOptions := workflow.ChildWorkflowOptions{}
+	setTimeout(&childOptions.ExecutionStartToCloseTimeout)
+
+	childCtx := workflow.WithChildOptions(ctx, childOptions)
+	return workflow.ExecuteChildWorkflow(childCtx, childWorkflowImpl).Get(ctx, nil)
+}
+
+func main() {
+	workflow.Register(workflowImpl)
+	workflow.Register(childWorkflowImpl)
+	activity.Register(activityImpl)
+	return
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"go.uber.org/cadence/workflow"
+)
+
+type recoveredError struct{}
+
+func (recoveredError) Error() string {
+	return "recovered from panic"
+}
+
+func recoverPanic(err *error) {
+	if r := recover(); r != nil {
+		*err = recoveredError{}
+	}
+}
+
+func validate(input string) {
+	if input == "" {
+		panic("empty input")
+	}
+}
+
+func workflowImpl(ctx workflow.Context, input string) (err error) {
+	defer recoverPanic(&err)
+
+	validate(input)
+	return nil
+}
+
+func main() {
+	workflow.Register(workflowImpl)
+	return
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"go.uber.org/cadence/workflow"
+	"time"
+)
+
+type status struct {
+	State    string
+	Progress int
+}
+
+func describe(s status) string {
+	return s.State
+}
+
+type progress struct {
+	Steps int
+}
+
+func (p *progress) steps() int {
+	return p.Steps
+}
+
+// withState returns a copy of s, modifying the copy only
+func withState(s status, state string) status {
+	s.State = state
+	return s
+}
+
+func workflowImpl(ctx workflow.Context) error {
+	current := status{State: "started"}
+
+	err := workflow.SetQueryHandler(ctx, "status", func() (string, error) {
+		return describe(current), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	state := &progress{}
+	counts := map[string]int{}
+	err = workflow.SetQueryHandler(ctx, "progress", func() (int, error) {
+		p := state
+		total := counts["progress"]
+		copied := withState(current, "queried")
+		return p.Steps + state.steps() + total + copied.Progress, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < 10; i++ {
+		current.Progress = i
+		state.Steps++
+		counts["progress"] = i
+		if err := workflow.Sleep(ctx, time.Minute); err != nil {
+			return err
+		}
+	}
+
+	current.State = "done"
+	return nil
+}
+
+func main() {
+	workflow.Register(workflowImpl)
+	return
+}
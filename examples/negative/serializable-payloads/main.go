@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/workflow"
+	"time"
+)
+
+// metadata is unexported, but its exported fields are promoted to the structs embedding it
+type metadata struct {
+	Owner string
+}
+
+// audit only has fields promoted from metadata
+type audit struct {
+	metadata
+}
+
+type node struct {
+	Name     string
+	Children []*node
+	Labels   map[string]string
+	Created  time.Time
+	internal bool
+	Ignored  func() `json:"-"`
+}
+
+func activityImpl(ctx context.Context, root *node, depth int, a audit) ([]string, error) {
+	return nil, nil
+}
+
+func workflowImpl(ctx workflow.Context, root node) ([]string, error) {
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		ScheduleToStartTimeout: time.Minute,
+		StartToCloseTimeout:    time.Minute,
+	})
+
+	var names []string
+	err := workflow.ExecuteActivity(ctx, activityImpl, &root, 3, audit{}).Get(ctx, &names)
+	return names, err
+}
+
+func main() {
+	workflow.Register(workflowImpl)
+	activity.Register(activityImpl)
+	return
+}
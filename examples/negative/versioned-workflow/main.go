@@ -0,0 +1,62 @@
+package main
+
+import (
+	"go.uber.org/cadence/workflow"
+	"time"
+)
+
+const (
+	changeRetries = "retries"
+	changeTimeout = "timeout"
+	changeReport  = "report"
+	changeDaily   = "daily"
+)
+
+func timeout(ctx workflow.Context) time.Duration {
+	if workflow.GetVersion(ctx, changeTimeout, workflow.DefaultVersion, 1) == workflow.DefaultVersion {
+		return time.Minute
+	}
+	return time.Hour
+}
+
+func workflowImpl(ctx workflow.Context) error {
+	switch workflow.GetVersion(ctx, changeRetries, workflow.DefaultVersion, 2) {
+	case workflow.DefaultVersion:
+		return workflow.Sleep(ctx, time.Second)
+	case 1:
+		return workflow.Sleep(ctx, time.Minute)
+	}
+
+	if err := workflow.Sleep(ctx, time.Hour); err != nil {
+		return err
+	}
+
+	return workflow.Sleep(ctx, timeout(ctx))
+}
+
+// report versions exclusive branches with their own change IDs
+func report(ctx workflow.Context, daily bool) error {
+	if daily {
+		if workflow.GetVersion(ctx, changeDaily, workflow.DefaultVersion, 1) == 1 {
+			return workflow.Sleep(ctx, time.Hour)
+		}
+	} else {
+		if workflow.GetVersion(ctx, changeReport, workflow.DefaultVersion, 2) == 2 {
+			return workflow.Sleep(ctx, time.Minute)
+		}
+	}
+	return nil
+}
+
+func reportWorkflow(ctx workflow.Context, daily bool) error {
+	if err := report(ctx, daily); err != nil {
+		return err
+	}
+	return workflow.Sleep(ctx, timeout(ctx))
+}
+
+func main() {
+	workflow.Register(workflowImpl)
+	workflow.Register(reportWorkflow)
+	return
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/workflow"
+	"time"
+)
+
+func activityImpl(ctx context.Context, name string, count int) (string, error) {
+	return name, nil
+}
+
+func workflowImpl(ctx workflow.Context) error {
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		ScheduleToStartTimeout: time.Minute,
+		StartToCloseTimeout:    time.Minute,
+	})
+
+	var name string
+	if err := workflow.ExecuteActivity(ctx, activityImpl, "name").Get(ctx, &name); err != nil {
+		return err
+	}
+
+	var count int
+	if err := workflow.ExecuteActivity(ctx, activityImpl, 1, "name").Get(ctx, &count); err != nil {
+		return err
+	}
+
+	if err := executeNamed(ctx, activityImpl); err != nil {
+		return err
+	}
+
+	var e executor = &namedExecutor{}
+	return e.execute(ctx, activityImpl)
+}
+
+// executeNamed executes the activity passed as a parameter of a function
+func executeNamed(ctx workflow.Context, fn interface{}) error {
+	return workflow.ExecuteActivity(ctx, fn, "name").Get(ctx, nil)
+}
+
+type executor interface {
+	execute(ctx workflow.Context, fn interface{}) error
+}
+
+type namedExecutor struct{}
+
+// execute executes the activity passed as a parameter of a method called through an interface
+func (*namedExecutor) execute(ctx workflow.Context, fn interface{}) error {
+	return workflow.ExecuteActivity(ctx, fn, "name", "count").Get(ctx, nil)
+}
+
+func main() {
+	workflow.Register(workflowImpl)
+	activity.Register(activityImpl)
+	return
+}
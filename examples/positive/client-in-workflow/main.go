@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/client"
+	"go.uber.org/cadence/workflow"
+)
+
+type notifier struct {
+	client client.Client
+}
+
+var notifications = &notifier{client: client.NewClient("domain")}
+
+func (n *notifier) notify(workflowID string) error {
+	return n.client.SignalWorkflow(context.Background(), workflowID, "", "notify", nil)
+}
+
+func childWorkflowImpl(ctx workflow.Context) error {
+	return nil
+}
+
+func workflowImpl(ctx workflow.Context) error {
+	activity.RecordHeartbeat(nil)
+
+	_, err := notifications.client.StartWorkflow(context.Background(), client.StartWorkflowOptions{}, childWorkflowImpl)
+	if err != nil {
+		return err
+	}
+
+	return notifications.notify("other-workflow")
+}
+
+func main() {
+	workflow.Register(workflowImpl)
+	workflow.Register(childWorkflowImpl)
+	return
+}
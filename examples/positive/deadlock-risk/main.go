@@ -0,0 +1,55 @@
+package main
+
+import (
+	"crypto/md5"
+	"go.uber.org/cadence/workflow"
+)
+
+func collatz(n int) int {
+	steps := 0
+	for n != 1 {
+		if n%2 == 0 {
+			n = n / 2
+		} else {
+			n = 3*n + 1
+		}
+		steps++
+	}
+	return steps
+}
+
+func sum(n int) int {
+	total := 0
+	for i := 0; i < n; i++ {
+		total += i
+	}
+	return total
+}
+
+func render(items []string) string {
+	result := ""
+	for _, item := range items {
+		result += item
+	}
+	for i := 0; i < 3; i++ {
+		result += "."
+	}
+	return result
+}
+
+func computeWorkflow(ctx workflow.Context, n int, items []string) error {
+	_ = collatz(n)
+	_ = sum(n)
+	_ = md5.Sum([]byte(render(items)))
+
+	done := false
+	for !done {
+		workflow.GetSignalChannel(ctx, "done").Receive(ctx, &done)
+	}
+	return nil
+}
+
+func main() {
+	workflow.Register(computeWorkflow)
+	return
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"go.uber.org/cadence/workflow"
+)
+
+var lastContext workflow.Context
+
+type workflows struct {
+	ctx      workflow.Context
+	contexts chan workflow.Context
+}
+
+func (w *workflows) remember(ctx workflow.Context) {
+	w.ctx = ctx
+}
+
+func background(ctx workflow.Context) {
+	workflow.GetLogger(ctx)
+}
+
+func (w *workflows) workflowImpl(ctx workflow.Context) error {
+	lastContext = ctx
+
+	ctx = workflow.WithValue(ctx, "key", "value")
+	w.remember(ctx)
+	w.contexts <- ctx
+
+	go background(ctx)
+	go func() {
+		background(ctx)
+	}()
+
+	return nil
+}
+
+func main() {
+	w := &workflows{}
+	workflow.Register(w.workflowImpl)
+	return
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/workflow"
+	"time"
+)
+
+func activityImpl(ctx context.Context) error {
+	return nil
+}
+
+func childWorkflowImpl(ctx workflow.Context) error {
+	return nil
+}
+
+func notify(ctx workflow.Context) {
+	workflow.SignalExternalWorkflow(ctx, "other-workflow", "", "notify", nil)
+}
+
+func workflowImpl(ctx workflow.Context) error {
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		ScheduleToStartTimeout: time.Minute,
+		StartToCloseTimeout:    time.Minute,
+	})
+
+	workflow.ExecuteActivity(ctx, activityImpl)
+
+	workflow.ExecuteActivity(ctx, activityImpl).Get(ctx, nil)
+
+	_ = workflow.NewTimer(ctx, time.Minute).Get(ctx, nil)
+
+	ctx = workflow.WithChildOptions(ctx, workflow.ChildWorkflowOptions{
+		ExecutionStartToCloseTimeout: time.Hour,
+	})
+	child := workflow.ExecuteChildWorkflow(ctx, childWorkflowImpl)
+	if err := child.SignalChildWorkflow(ctx, "notify", nil).Get(ctx, nil); err != nil {
+		return err
+	}
+
+	notify(ctx)
+	return nil
+}
+
+func main() {
+	workflow.Register(workflowImpl)
+	workflow.Register(childWorkflowImpl)
+	activity.Register(activityImpl)
+	return
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/workflow"
+	"time"
+)
+
+type status struct {
+	State   string
+	Queries int
+}
+
+type progress struct {
+	Steps int
+}
+
+func (p *progress) touch() {
+	p.Steps++
+}
+
+func activityImpl(ctx context.Context) (string, error) {
+	return "done", nil
+}
+
+func refresh(ctx workflow.Context) (string, error) {
+	var state string
+	err := workflow.ExecuteActivity(ctx, activityImpl).Get(ctx, &state)
+	return state, err
+}
+
+func workflowImpl(ctx workflow.Context) error {
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		ScheduleToStartTimeout: time.Minute,
+		StartToCloseTimeout:    time.Minute,
+	})
+
+	current := status{State: "started"}
+	updates := workflow.GetSignalChannel(ctx, "update")
+
+	err := workflow.SetQueryHandler(ctx, "status", func() (status, error) {
+		current.Queries++
+
+		var update string
+		updates.ReceiveAsync(&update)
+
+		state, err := refresh(ctx)
+		return status{State: state + update, Queries: current.Queries}, err
+	})
+	if err != nil {
+		return err
+	}
+
+	state := &progress{}
+	counts := map[string]int{}
+	err = workflow.SetQueryHandler(ctx, "progress", func() (int, error) {
+		p := state
+		p.Steps++
+		counts["progress"]++
+		state.touch()
+		return p.Steps, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return workflow.Sleep(ctx, time.Hour)
+}
+
+func main() {
+	workflow.Register(workflowImpl)
+	activity.Register(activityImpl)
+	return
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"go.uber.org/cadence"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/workflow"
+	"time"
+)
+
+const taskList = ""
+
+func activityImpl(ctx context.Context) error {
+	return nil
+}
+
+func childWorkflowImpl(ctx workflow.Context) error {
+	return nil
+}
+
+func workflowImpl(ctx workflow.Context) error {
+	activityCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		TaskList:               taskList,
+		ScheduleToStartTimeout: time.Minute,
+		StartToCloseTimeout:    10 * time.Minute,
+		ScheduleToCloseTimeout: 5 * time.Minute,
+		RetryPolicy: &cadence.RetryPolicy{
+			InitialInterval:    time.Second,
+			BackoffCoefficient: -2,
+			MaximumAttempts:    5,
+		},
+	})
+	if err := workflow.ExecuteActivity(activityCtx, activityImpl).Get(ctx, nil); err != nil {
+		return err
+	}
+
+	localCtx := workflow.WithLocalActivityOptions(ctx, workflow.LocalActivityOptions{})
+	if err := workflow.ExecuteLocalActivity(localCtx, activityImpl).Get(ctx, nil); err != nil {
+		return err
+	}
+
+	childCtx := workflow.WithChildOptions(ctx, workflow.ChildWorkflowOptions{
+		TaskStartToCloseTimeout: -time.Second,
+	})
+	return workflow.ExecuteChildWorkflow(childCtx, childWorkflowImpl).Get(ctx, nil)
+}
+
+func main() {
+	workflow.Register(workflowImpl)
+	workflow.Register(childWorkflowImpl)
+	activity.Register(activityImpl)
+	return
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"go.uber.org/cadence/workflow"
+	"time"
+)
+
+const (
+	changeRetries = "retries"
+	changeTimeout = "timeout"
+)
+
+func versionOf(ctx workflow.Context, changeID string) workflow.Version {
+	return workflow.GetVersion(ctx, changeID, workflow.DefaultVersion, 1)
+}
+
+func workflowImpl(ctx workflow.Context, name string) error {
+	v := workflow.GetVersion(ctx, changeRetries, workflow.DefaultVersion, 2)
+	if v == 3 {
+		return workflow.Sleep(ctx, time.Minute)
+	}
+
+	if workflow.GetVersion(ctx, changeTimeout, 2, 1) == 1 {
+		return workflow.Sleep(ctx, time.Second)
+	}
+
+	if workflow.GetVersion(ctx, changeRetries, workflow.DefaultVersion, 3) == 2 {
+		return workflow.Sleep(ctx, time.Hour)
+	}
+
+	versionOf(ctx, name)
+	return nil
+}
+
+func main() {
+	workflow.Register(workflowImpl)
+	return
+}
@@ -0,0 +1,23 @@
+// Package library has no main function, but registers its workflows in init: its other functions with the signature
+// of a workflow are helpers
+package library
+
+import (
+	"go.uber.org/cadence/workflow"
+	"time"
+)
+
+func init() {
+	workflow.Register(reminderWorkflow)
+}
+
+func reminderWorkflow(ctx workflow.Context) error {
+	workflow.NewTimer(ctx, time.Hour)
+	return nil
+}
+
+// WaitForApproval is called from workflows of the programs importing this package, not a workflow itself
+func WaitForApproval(ctx workflow.Context) error {
+	workflow.NewTimer(ctx, time.Minute)
+	return nil
+}
@@ -0,0 +1,34 @@
+// Package library has no main function and registers no workflows: its exported workflows are registered by the
+// programs importing it
+package library
+
+import (
+	"go.uber.org/cadence/workflow"
+	"time"
+)
+
+// OrderWorkflow is registered by the programs importing this package
+func OrderWorkflow(ctx workflow.Context, orderID string) (string, error) {
+	if err := waitForPayment(ctx); err != nil {
+		return "", err
+	}
+
+	workflow.NewTimer(ctx, time.Minute)
+	return orderID, nil
+}
+
+// waitForPayment has the signature of a workflow, but is a helper which can't be registered by other programs
+func waitForPayment(ctx workflow.Context) error {
+	workflow.NewTimer(ctx, time.Hour)
+	return nil
+}
+
+// WaitFor is a helper called from workflows of the programs importing this package, not a workflow itself
+func WaitFor(ctx workflow.Context, d time.Duration) {
+	_ = workflow.Sleep(ctx, d)
+}
+
+// StartedAt is called by the programs importing this package, outside of any workflow
+func StartedAt() time.Time {
+	return workflow.Now(nil)
+}
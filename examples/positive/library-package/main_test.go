@@ -0,0 +1,16 @@
+package library
+
+import (
+	"context"
+	"go.uber.org/cadence/activity"
+	"testing"
+)
+
+// pingActivity is only registered by tests
+func pingActivity(ctx context.Context) error {
+	return nil
+}
+
+func TestRegister(t *testing.T) {
+	activity.Register(pingActivity)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/workflow"
+	"time"
+)
+
+func activityImpl(ctx context.Context) error {
+	return nil
+}
+
+func childWorkflowImpl(ctx workflow.Context) error {
+	return nil
+}
+
+func schedule(ctx workflow.Context) error {
+	return workflow.ExecuteActivity(ctx, activityImpl).Get(ctx, nil)
+}
+
+func scheduleWithOptions(ctx workflow.Context, configure bool) error {
+	if configure {
+		ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+			ScheduleToStartTimeout: time.Minute,
+			StartToCloseTimeout:    time.Minute,
+		})
+	}
+	return schedule(ctx)
+}
+
+func workflowImpl(ctx workflow.Context) error {
+	if err := workflow.ExecuteActivity(ctx, activityImpl).Get(ctx, nil); err != nil {
+		return err
+	}
+
+	if err := scheduleWithOptions(ctx, true); err != nil {
+		return err
+	}
+
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		ScheduleToStartTimeout: time.Minute,
+		StartToCloseTimeout:    time.Minute,
+	})
+	return workflow.ExecuteChildWorkflow(ctx, childWorkflowImpl).Get(ctx, nil)
+}
+
+func main() {
+	workflow.Register(workflowImpl)
+	workflow.Register(childWorkflowImpl)
+	activity.Register(activityImpl)
+	return
+}
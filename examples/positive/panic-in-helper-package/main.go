@@ -0,0 +1,18 @@
+package main
+
+import (
+	"github.com/sema/cadencecheck/examples/positive/panic-in-helper-package/safe"
+	"go.uber.org/cadence/workflow"
+)
+
+func workflowImpl(ctx workflow.Context, input string) (err error) {
+	defer safe.Recover(&err)
+
+	safe.MustNotBeEmpty(input)
+	return nil
+}
+
+func main() {
+	workflow.Register(workflowImpl)
+	return
+}
@@ -0,0 +1,21 @@
+package safe
+
+type recoveredError struct{}
+
+func (recoveredError) Error() string {
+	return "recovered from panic"
+}
+
+// Recover is deferred to turn a panic into an error
+func Recover(err *error) {
+	if r := recover(); r != nil {
+		*err = recoveredError{}
+	}
+}
+
+// MustNotBeEmpty panics if value is empty
+func MustNotBeEmpty(value string) {
+	if value == "" {
+		panic("empty value")
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"go.uber.org/cadence/workflow"
+	"time"
+)
+
+const (
+	changeRetries = "retries"
+	changeReport  = "report"
+)
+
+func workflowImpl(ctx workflow.Context) error {
+	if workflow.GetVersion(ctx, changeRetries, workflow.DefaultVersion, 2) == 1 {
+		return workflow.Sleep(ctx, time.Minute)
+	}
+
+	// Reusing a change ID with the same range returns the version recorded by the first call
+	if workflow.GetVersion(ctx, changeRetries, workflow.DefaultVersion, 2) == 2 {
+		return workflow.Sleep(ctx, time.Hour)
+	}
+	return nil
+}
+
+// report reuses a change ID with differing ranges on exclusive branches, only one of which runs in an execution
+func report(ctx workflow.Context, daily bool) error {
+	if daily {
+		if workflow.GetVersion(ctx, changeReport, workflow.DefaultVersion, 1) == 1 {
+			return workflow.Sleep(ctx, time.Hour)
+		}
+	} else {
+		if workflow.GetVersion(ctx, changeReport, workflow.DefaultVersion, 2) == 2 {
+			return workflow.Sleep(ctx, time.Minute)
+		}
+	}
+	return nil
+}
+
+func reportWorkflow(ctx workflow.Context, daily bool) error {
+	return report(ctx, daily)
+}
+
+func main() {
+	workflow.Register(workflowImpl)
+	workflow.Register(reportWorkflow)
+	return
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"go.uber.org/cadence/client"
+	"go.uber.org/cadence/workflow"
+)
+
+type approval struct {
+	Approver string
+}
+
+type rejection struct {
+	Reason string
+}
+
+func approvalWorkflow(ctx workflow.Context) error {
+	status := "waiting"
+	err := workflow.SetQueryHandler(ctx, "status", func() (string, error) {
+		return status, nil
+	})
+	if err != nil {
+		return err
+	}
+	err = workflow.SetQueryHandler(ctx, "progress", func() (int, error) {
+		return 0, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var paused bool
+	workflow.GetSignalChannel(ctx, "pause").ReceiveAsync(&paused)
+
+	var retries int
+	workflow.GetSignalChannel(ctx, "retries").ReceiveAsync(&retries)
+
+	cancelled := false
+	selector := workflow.NewSelector(ctx)
+	selector.AddReceive(workflow.GetSignalChannel(ctx, "approve"), func(c workflow.Channel, more bool) {
+		var a approval
+		c.Receive(ctx, &a)
+		status = "approved"
+	})
+	selector.AddReceive(workflow.GetSignalChannel(ctx, "cancel"), func(c workflow.Channel, more bool) {
+		var r rejection
+		c.Receive(ctx, &r)
+		cancelled = true
+	})
+	selector.Select(ctx)
+
+	if cancelled {
+		status = "cancelled"
+	}
+	return nil
+}
+
+func approve(c client.Client, workflowID string) error {
+	return c.SignalWorkflow(context.Background(), workflowID, "", "aprove", approval{Approver: "alice"})
+}
+
+func approveByPointer(c client.Client, workflowID string) error {
+	return c.SignalWorkflow(context.Background(), workflowID, "", "approve", &approval{Approver: "bob"})
+}
+
+func cancel(c client.Client, workflowID string) error {
+	return c.SignalWorkflow(context.Background(), workflowID, "", "cancel", "no longer needed")
+}
+
+func retry(c client.Client, workflowID string) error {
+	return c.SignalWorkflow(context.Background(), workflowID, "", "retries", 1.5)
+}
+
+func status(c client.Client, workflowID string) error {
+	if _, err := c.QueryWorkflow(context.Background(), workflowID, "", "status"); err != nil {
+		return err
+	}
+	if _, err := c.QueryWorkflow(context.Background(), workflowID, "", "__stack_trace"); err != nil {
+		return err
+	}
+	_, err := c.QueryWorkflow(context.Background(), workflowID, "", "state")
+	return err
+}
+
+type reason string
+
+// approvalRecord has the same JSON fields as approval
+type approvalRecord struct {
+	Approver string `json:"approver"`
+}
+
+// auditWorkflow receives payloads whose types differ from the sent types, but which decode from their JSON encoding
+func auditWorkflow(ctx workflow.Context) error {
+	var days int64
+	workflow.GetSignalChannel(ctx, "extend").Receive(ctx, &days)
+
+	var r reason
+	workflow.GetSignalChannel(ctx, "reason").Receive(ctx, &r)
+
+	var rate float64
+	workflow.GetSignalChannel(ctx, "rate").Receive(ctx, &rate)
+
+	var record approvalRecord
+	workflow.GetSignalChannel(ctx, "record").Receive(ctx, &record)
+	return nil
+}
+
+func audit(c client.Client, workflowID string) error {
+	if err := c.SignalWorkflow(context.Background(), workflowID, "", "extend", 3); err != nil {
+		return err
+	}
+	if err := c.SignalWorkflow(context.Background(), workflowID, "", "reason", "audit"); err != nil {
+		return err
+	}
+	if err := c.SignalWorkflow(context.Background(), workflowID, "", "rate", 2); err != nil {
+		return err
+	}
+	return c.SignalWorkflow(context.Background(), workflowID, "", "record", approval{Approver: "carol"})
+}
+
+func main() {
+	workflow.Register(approvalWorkflow)
+	workflow.Register(auditWorkflow)
+
+	c := client.NewClient("domain")
+	_ = approve(c, "approval")
+	_ = approveByPointer(c, "approval")
+	_ = cancel(c, "approval")
+	_ = retry(c, "approval")
+	_ = status(c, "approval")
+	_ = audit(c, "audit")
+	return
+}
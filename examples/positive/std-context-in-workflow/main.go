@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"go.uber.org/cadence/workflow"
+)
+
+type lookup struct {
+	Key string
+}
+
+func fetch(ctx context.Context, key string) string {
+	return key
+}
+
+func fetchAll(ctx context.Context, keys []string) []string {
+	var result []string
+	for _, key := range keys {
+		result = append(result, fetch(ctx, key))
+	}
+	return result
+}
+
+func workflowImpl(ctx workflow.Context, keys []string) error {
+	fetchAll(context.WithValue(context.Background(), "workflow", "id"), keys)
+
+	// contexts used within a side effect are fine, as long as they don't leave it
+	workflow.SideEffect(ctx, func(ctx workflow.Context) interface{} {
+		return fetch(context.Background(), "key")
+	})
+
+	var leaked context.Context
+	workflow.SideEffect(ctx, func(ctx workflow.Context) interface{} {
+		leaked = context.TODO()
+		return nil
+	})
+	fetch(leaked, "key")
+
+	return nil
+}
+
+func main() {
+	workflow.Register(workflowImpl)
+	return
+}
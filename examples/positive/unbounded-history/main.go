@@ -0,0 +1,108 @@
+package main
+
+import (
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/workflow"
+	"time"
+)
+
+func poll() error {
+	return nil
+}
+
+func process(item string) error {
+	return nil
+}
+
+func withOptions(ctx workflow.Context) workflow.Context {
+	return workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		ScheduleToStartTimeout: time.Minute,
+		StartToCloseTimeout:    time.Minute,
+	})
+}
+
+func pollingWorkflow(ctx workflow.Context) error {
+	ctx = withOptions(ctx)
+	for {
+		if err := workflow.ExecuteActivity(ctx, poll).Get(ctx, nil); err != nil {
+			return err
+		}
+		if err := workflow.Sleep(ctx, time.Minute); err != nil {
+			return err
+		}
+	}
+}
+
+func processItem(ctx workflow.Context, item string) error {
+	return workflow.ExecuteActivity(ctx, process, item).Get(ctx, nil)
+}
+
+func queueWorkflow(ctx workflow.Context) error {
+	ctx = withOptions(ctx)
+	items := workflow.GetSignalChannel(ctx, "item")
+
+	done := false
+	for !done {
+		var item string
+		items.Receive(ctx, &item)
+		if item == "" {
+			done = true
+			continue
+		}
+		if err := processItem(ctx, item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// retryStep and backoffStep are mutually recursive, only retryStep produces decisions itself
+func retryStep(ctx workflow.Context, attempt int) error {
+	if attempt > 0 {
+		if err := backoffStep(ctx, attempt-1); err != nil {
+			return err
+		}
+	}
+	return workflow.ExecuteActivity(ctx, poll).Get(ctx, nil)
+}
+
+func backoffStep(ctx workflow.Context, attempt int) error {
+	return retryStep(ctx, attempt)
+}
+
+func retryWorkflow(ctx workflow.Context) error {
+	ctx = withOptions(ctx)
+	retries := workflow.GetSignalChannel(ctx, "retry")
+	backoffs := workflow.GetSignalChannel(ctx, "backoff")
+
+	for {
+		var attempt int
+		retries.Receive(ctx, &attempt)
+		if attempt < 0 {
+			break
+		}
+		if err := retryStep(ctx, attempt); err != nil {
+			return err
+		}
+	}
+
+	for {
+		var attempt int
+		backoffs.Receive(ctx, &attempt)
+		if attempt < 0 {
+			return nil
+		}
+		if err := backoffStep(ctx, attempt); err != nil {
+			return err
+		}
+	}
+}
+
+func main() {
+	workflow.Register(pollingWorkflow)
+	workflow.Register(queueWorkflow)
+	workflow.Register(retryWorkflow)
+	activity.Register(poll)
+	activity.Register(process)
+	return
+}
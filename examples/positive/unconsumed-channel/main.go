@@ -0,0 +1,34 @@
+package main
+
+import (
+	"go.uber.org/cadence/workflow"
+)
+
+func countSignal(c workflow.Channel, count *int) {
+	*count++
+}
+
+func workflowImpl(ctx workflow.Context) error {
+	signals := workflow.GetSignalChannel(ctx, "signal")
+	cancellations := workflow.GetSignalChannel(ctx, "cancel")
+	signalCount := 0
+	cancelled := false
+
+	selector := workflow.NewSelector(ctx)
+	selector.AddReceive(signals, func(c workflow.Channel, more bool) {
+		countSignal(c, &signalCount)
+	})
+	selector.AddReceive(cancellations, func(c workflow.Channel, more bool) {
+		cancelled = true
+	})
+
+	for !cancelled {
+		selector.Select(ctx)
+	}
+	return nil
+}
+
+func main() {
+	workflow.Register(workflowImpl)
+	return
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/workflow"
+	"time"
+)
+
+type request struct {
+	ID       string
+	Callback func() error
+	Updates  chan string
+	Metadata map[token]string
+}
+
+type token struct {
+	value string
+}
+
+type response struct {
+	Token token
+	Err   error
+}
+
+func activityImpl(ctx context.Context, r request) (*response, error) {
+	return &response{}, nil
+}
+
+func notifyActivityImpl(ctx context.Context, payload interface{}) error {
+	return nil
+}
+
+func workflowImpl(ctx workflow.Context, r request) error {
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		ScheduleToStartTimeout: time.Minute,
+		StartToCloseTimeout:    time.Minute,
+	})
+
+	return workflow.ExecuteActivity(ctx, notifyActivityImpl, token{value: r.ID}).Get(ctx, nil)
+}
+
+func main() {
+	workflow.Register(workflowImpl)
+	activity.Register(activityImpl)
+	activity.Register(notifyActivityImpl)
+	return
+}
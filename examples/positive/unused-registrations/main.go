@@ -0,0 +1,68 @@
+package main
+
+import (
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/client"
+	"go.uber.org/cadence/workflow"
+	"time"
+)
+
+func reserve(item string) error {
+	return nil
+}
+
+func charge(item string) error {
+	return nil
+}
+
+func legacyCharge(item string) error {
+	return nil
+}
+
+func audit(item string) error {
+	return nil
+}
+
+func withOptions(ctx workflow.Context) workflow.Context {
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		ScheduleToStartTimeout: time.Minute,
+		StartToCloseTimeout:    time.Minute,
+	})
+	return workflow.WithChildOptions(ctx, workflow.ChildWorkflowOptions{
+		ExecutionStartToCloseTimeout: time.Hour,
+	})
+}
+
+func orderWorkflow(ctx workflow.Context, item string) error {
+	ctx = withOptions(ctx)
+	if err := workflow.ExecuteActivity(ctx, reserve, item).Get(ctx, nil); err != nil {
+		return err
+	}
+	return workflow.ExecuteChildWorkflow(ctx, "payment", item).Get(ctx, nil)
+}
+
+func paymentWorkflow(ctx workflow.Context, item string) error {
+	ctx = withOptions(ctx)
+	return workflow.ExecuteActivity(ctx, "charge", item).Get(ctx, nil)
+}
+
+func legacyOrderWorkflow(ctx workflow.Context, item string) error {
+	ctx = withOptions(ctx)
+	return workflow.ExecuteActivity(ctx, legacyCharge, item).Get(ctx, nil)
+}
+
+func startOrder(c client.Client, item string) {
+	c.StartWorkflow(nil, client.StartWorkflowOptions{}, orderWorkflow, item)
+}
+
+func main() {
+	workflow.Register(orderWorkflow)
+	workflow.RegisterWithOptions(paymentWorkflow, workflow.RegisterOptions{Name: "payment"})
+	workflow.Register(legacyOrderWorkflow)
+	activity.Register(reserve)
+	activity.RegisterWithOptions(charge, activity.RegisterOptions{Name: "charge"})
+	activity.Register(legacyCharge)
+	activity.Register(audit)
+	startOrder(nil, "book")
+	return
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"go.uber.org/cadence/workflow"
+	"time"
+)
+
+const changeTimer = "timer"
+
+var lastContext workflow.Context
+
+func wait(ctx workflow.Context, d time.Duration) error {
+	if d < 0 {
+		panic("negative duration")
+	}
+	return workflow.Sleep(ctx, d)
+}
+
+// legacyWait is only called for the old version of the change
+func legacyWait(ctx workflow.Context) error {
+	lastContext = ctx
+	return workflow.Sleep(ctx, time.Minute)
+}
+
+func workflowImpl(ctx workflow.Context) error {
+	v := workflow.GetVersion(ctx, changeTimer, workflow.DefaultVersion, 1)
+	if v == workflow.DefaultVersion {
+		lastContext = ctx
+		// wait is called for both versions, but reached through the old version first
+		if err := wait(ctx, time.Second); err != nil {
+			return err
+		}
+		return legacyWait(ctx)
+	}
+
+	return wait(ctx, time.Minute)
+}
+
+func main() {
+	workflow.Register(workflowImpl)
+	return
+}
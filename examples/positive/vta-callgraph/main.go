@@ -0,0 +1,51 @@
+package main
+
+import (
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/workflow"
+)
+
+type step interface {
+	run(ctx workflow.Context) error
+}
+
+type heartbeatStep struct{}
+
+func (s heartbeatStep) run(ctx workflow.Context) error {
+	activity.RecordHeartbeat(nil)
+	return nil
+}
+
+type loggingStep struct{}
+
+func (s loggingStep) run(ctx workflow.Context) error {
+	activity.GetLogger(nil)
+	return nil
+}
+
+// reportStep is only run by main, so type propagation does not consider it a callee of step.run in runStep
+type reportStep struct{}
+
+func (s reportStep) run(ctx workflow.Context) error {
+	activity.RecordHeartbeat(nil)
+	return nil
+}
+
+func runStep(ctx workflow.Context, s step) error {
+	return s.run(ctx)
+}
+
+func workflowImpl(ctx workflow.Context) error {
+	if err := runStep(ctx, heartbeatStep{}); err != nil {
+		return err
+	}
+	return runStep(ctx, loggingStep{})
+}
+
+func main() {
+	workflow.Register(workflowImpl)
+
+	var report step = reportStep{}
+	_ = report.run(nil)
+	return
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/workflow"
+	"time"
+)
+
+// backoff is shared between workflow and activity code, and not reported
+func backoff(ctx workflow.Context, attempt int) error {
+	return workflow.Sleep(ctx, time.Duration(attempt)*time.Second)
+}
+
+func logAttempt(ctx workflow.Context, attempt int) {
+	workflow.GetLogger(ctx)
+}
+
+func now(ctx workflow.Context) time.Time {
+	return workflow.Now(ctx)
+}
+
+func activityImpl(ctx context.Context, attempt int) error {
+	// there is no workflow.Context in activities
+	logAttempt(nil, attempt)
+	if attempt > 0 {
+		return backoff(nil, attempt)
+	}
+	return nil
+}
+
+func workflowImpl(ctx workflow.Context) error {
+	return backoff(ctx, 1)
+}
+
+func main() {
+	workflow.Register(workflowImpl)
+	activity.Register(activityImpl)
+
+	started := now(nil)
+	_ = started
+	return
+}
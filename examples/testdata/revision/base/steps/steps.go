@@ -0,0 +1,13 @@
+package steps
+
+import (
+	"go.uber.org/cadence/workflow"
+)
+
+func chargeActivity(orderID string) error {
+	return nil
+}
+
+func Charge(ctx workflow.Context, orderID string) error {
+	return workflow.ExecuteActivity(ctx, chargeActivity, orderID).Get(ctx, nil)
+}
@@ -0,0 +1,14 @@
+package main
+
+import (
+	"github.com/sema/cadencecheck/examples/testdata/workspace/steps"
+	"go.uber.org/cadence/workflow"
+)
+
+func main() {
+	workflow.Register(orderWorkflow)
+}
+
+func orderWorkflow(ctx workflow.Context, orderID string) error {
+	return steps.Charge(ctx, orderID)
+}
@@ -0,0 +1,17 @@
+package steps
+
+import (
+	"go.uber.org/cadence/workflow"
+	"time"
+)
+
+func chargeActivity(orderID string) error {
+	return nil
+}
+
+func Charge(ctx workflow.Context, orderID string) error {
+	if err := workflow.Sleep(ctx, time.Minute); err != nil {
+		return err
+	}
+	return workflow.ExecuteActivity(ctx, chargeActivity, orderID).Get(ctx, nil)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/workflow"
+)
+
+func reserve(item string) error {
+	return nil
+}
+
+func charge(item string) error {
+	return nil
+}
+
+func orderWorkflow(ctx workflow.Context, item string) error {
+	if err := workflow.ExecuteActivity(ctx, reserve, item).Get(ctx, nil); err != nil {
+		return err
+	}
+	return workflow.ExecuteChildWorkflow(ctx, "payment", item).Get(ctx, nil)
+}
+
+func paymentWorkflow(ctx workflow.Context, item string) error {
+	if err := workflow.ExecuteActivity(ctx, charge, item).Get(ctx, nil); err != nil {
+		return workflow.ExecuteChildWorkflow(ctx, retryWorkflow, item).Get(ctx, nil)
+	}
+	return nil
+}
+
+func retryWorkflow(ctx workflow.Context, item string) error {
+	return workflow.ExecuteChildWorkflow(ctx, "payment", item).Get(ctx, nil)
+}
+
+func registerPayments() {
+	workflow.RegisterWithOptions(paymentWorkflow, workflow.RegisterOptions{Name: "payment"})
+	workflow.Register(retryWorkflow)
+	activity.Register(charge)
+}
+
+func main() {
+	workflow.Register(orderWorkflow)
+	activity.Register(reserve)
+	registerPayments()
+	return
+}
@@ -0,0 +1,70 @@
+package analysis
+
+import (
+	"github.com/sema/cadencecheck/pkg/entities"
+	"go/types"
+)
+
+const (
+	_cadenceInternalPackage = "go.uber.org/cadence/internal"
+)
+
+// IsNamedType returns true if typ is the named type pkgPath.name
+func IsNamedType(typ types.Type, pkgPath string, name string) bool {
+	named, ok := typ.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return false
+	}
+
+	return entities.StripVendor(named.Obj().Pkg().Path()) == pkgPath && named.Obj().Name() == name
+}
+
+// IsWorkflowContext returns true if typ is workflow.Context
+func IsWorkflowContext(typ types.Type) bool {
+	// workflow.Context is an alias of internal.Context
+	return IsNamedType(typ, _cadenceInternalPackage, "Context")
+}
+
+// IsContext returns true if typ is context.Context from the standard library
+func IsContext(typ types.Type) bool {
+	return IsNamedType(typ, "context", "Context")
+}
+
+// IsWorkflowSignature returns true if sig matches the signature Cadence requires of workflow functions, i.e. it
+// takes a workflow.Context as its first parameter and returns either an error, or a result and an error
+func IsWorkflowSignature(sig *types.Signature) bool {
+	if sig.Params().Len() == 0 || !IsWorkflowContext(sig.Params().At(0).Type()) {
+		return false
+	}
+
+	results := sig.Results()
+	if results.Len() != 1 && results.Len() != 2 {
+		return false
+	}
+	return types.Identical(results.At(results.Len()-1).Type(), types.Universe.Lookup("error").Type())
+}
+
+// PayloadParams returns the parameters of a workflow or activity function carrying payload, i.e. all parameters
+// except a leading workflow.Context or context.Context
+func PayloadParams(sig *types.Signature) []*types.Var {
+	var params []*types.Var
+	for i := 0; i < sig.Params().Len(); i++ {
+		params = append(params, sig.Params().At(i))
+	}
+
+	if len(params) > 0 && (IsWorkflowContext(params[0].Type()) || IsContext(params[0].Type())) {
+		params = params[1:]
+	}
+
+	return params
+}
+
+// PayloadResult returns the type of the result of a workflow or activity function, or nil if the function only
+// returns an error
+func PayloadResult(sig *types.Signature) types.Type {
+	if sig.Results().Len() < 2 {
+		return nil
+	}
+
+	return sig.Results().At(0).Type()
+}
@@ -0,0 +1,57 @@
+package analysis
+
+import (
+	"github.com/sema/cadencecheck/pkg/entities"
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/ssa"
+)
+
+// CallSite is a call instruction found in a function reachable from some root function
+type CallSite struct {
+	Instruction ssa.CallInstruction
+	Signature   entities.FunctionPattern
+
+	// StackTrace leads from the root function to the function containing Instruction
+	StackTrace []*callgraph.Edge
+}
+
+// VersionGuards returns the GetVersion guards the call is made within, see PathVersionGuards
+func (c CallSite) VersionGuards() []entities.VersionGuard {
+	return PathVersionGuards(c.Instruction, c.StackTrace)
+}
+
+// FindCallSites returns all calls, in application code reachable from root, to functions matching one of patterns
+//
+// Calls are matched statically using CallSignature, i.e. interface method invocations are matched against the
+// interface method rather than its implementations.
+func FindCallSites(root *callgraph.Node, patterns []entities.FunctionPattern) []CallSite {
+	patternMap := map[entities.FunctionPattern]bool{}
+	for _, p := range patterns {
+		patternMap[p] = true
+	}
+
+	var result []CallSite
+	VisitReachableFunctions(root, func(f *ssa.Function, stackTrace []*callgraph.Edge) {
+		for _, block := range f.Blocks {
+			for _, instr := range block.Instrs {
+				call, ok := instr.(ssa.CallInstruction)
+				if !ok {
+					continue
+				}
+
+				signature, err := CallSignature(call.Common())
+				if err != nil || !patternMap[signature] {
+					continue
+				}
+
+				result = append(result, CallSite{
+					Instruction: call,
+					Signature:   signature,
+					StackTrace:  stackTrace,
+				})
+			}
+		}
+	})
+
+	return result
+}
@@ -0,0 +1,134 @@
+package analysis
+
+import (
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/ssa"
+	"sort"
+)
+
+type callback func(edge *callgraph.Edge, previous []*callgraph.Edge) (follow bool)
+
+func GraphVisitEdges(root *callgraph.Node, callback callback) {
+	stack := make([]*callgraph.Edge, 0, 32)
+	visited := make(map[*callgraph.Node]bool)
+
+	visit(root, callback, visited, stack)
+}
+
+func visit(n *callgraph.Node, callback callback, visited map[*callgraph.Node]bool, stack []*callgraph.Edge) {
+	if visited[n] {
+		return
+	}
+	visited[n] = true
+
+	for _, edge := range n.Out {
+		follow := callback(edge, stack)
+		if !follow {
+			continue
+		}
+
+		visit(edge.Callee, callback, visited, append(stack, edge))
+	}
+}
+
+type functionCallback func(f *ssa.Function, stackTrace []*callgraph.Edge)
+
+// VisitReachableFunctions calls callback once for root and for every function reachable from root, along with the
+// stack trace through which the function was first reached (empty for root itself).
+//
+// Library functions (see IsLibraryFunction) are never visited nor followed, as checks are concerned with the
+// application code calling into libraries - not with the libraries themselves.
+func VisitReachableFunctions(root *callgraph.Node, callback functionCallback) {
+	if IsLibraryFunction(root.Func) {
+		return
+	}
+
+	visited := map[*ssa.Function]bool{root.Func: true}
+	callback(root.Func, nil)
+
+	GraphVisitEdges(root, func(edge *callgraph.Edge, previous []*callgraph.Edge) (follow bool) {
+		callee := edge.Callee.Func
+		if visited[callee] || IsLibraryFunction(callee) {
+			return false
+		}
+		visited[callee] = true
+
+		stackTrace := append(append([]*callgraph.Edge{}, previous...), edge)
+		callback(callee, stackTrace)
+
+		return true
+	})
+}
+
+// FindPathFromRoot returns the shortest chain of calls leading from a root function to target, or nil if target is
+// not reachable from any root. Returns an empty, non-nil path if target is itself a root.
+func FindPathFromRoot(target *callgraph.Node, isRoot func(f *ssa.Function) bool) []*callgraph.Edge {
+	// Breadth-first search through callers, recording the edge through which each node was first reached
+	next := map[*callgraph.Node]*callgraph.Edge{}
+	visited := map[*callgraph.Node]bool{target: true}
+	queue := []*callgraph.Node{target}
+
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+
+		if isRoot(n.Func) {
+			path := []*callgraph.Edge{}
+			for edge := next[n]; edge != nil; edge = next[edge.Callee] {
+				path = append(path, edge)
+			}
+			return path
+		}
+
+		for _, edge := range n.In {
+			if visited[edge.Caller] {
+				continue
+			}
+			visited[edge.Caller] = true
+			next[edge.Caller] = edge
+			queue = append(queue, edge.Caller)
+		}
+	}
+
+	return nil
+}
+
+// FindRoots returns all functions matching isRoot from which target is reachable, in breadth-first order
+func FindRoots(target *callgraph.Node, isRoot func(f *ssa.Function) bool) []*ssa.Function {
+	var roots []*ssa.Function
+	visited := map[*callgraph.Node]bool{target: true}
+	queue := []*callgraph.Node{target}
+
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+
+		if isRoot(n.Func) {
+			roots = append(roots, n.Func)
+		}
+
+		for _, edge := range n.In {
+			if !visited[edge.Caller] {
+				visited[edge.Caller] = true
+				queue = append(queue, edge.Caller)
+			}
+		}
+	}
+
+	return roots
+}
+
+// SortedFunctions returns the functions of the call graph in a stable order
+func SortedFunctions(callGraph *callgraph.Graph) []*ssa.Function {
+	var result []*ssa.Function
+	for f := range callGraph.Nodes {
+		if f != nil {
+			result = append(result, f)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].String() < result[j].String()
+	})
+	return result
+}
@@ -0,0 +1,190 @@
+package analysis
+
+import (
+	"go/constant"
+	"go/token"
+	"go/types"
+	"golang.org/x/tools/go/ssa"
+)
+
+// StructLiteral is the statically known content of a struct value created from a composite literal
+//
+// Fields which are never assigned hold their zero value. Fields assigned more than once, or assigned a value which
+// is neither a constant nor another composite literal, are unknown.
+type StructLiteral struct {
+	// Alloc is the allocation of the composite literal, nil for empty composite literals which SSA represents as
+	// constant zero values
+	Alloc   *ssa.Alloc
+	fields  map[string]ssa.Value
+	unknown map[string]bool
+}
+
+// FoldStruct resolves the fields of a struct value (or pointer to a struct) created by a composite literal
+//
+// Returns false if value does not originate from a local composite literal, or if the struct or one of its fields
+// may be modified other than by assigning its fields, e.g. by passing its address to a function.
+func FoldStruct(value ssa.Value) (*StructLiteral, bool) {
+	if load, ok := value.(*ssa.UnOp); ok && load.Op == token.MUL {
+		value = load.X
+	}
+
+	if c, ok := value.(*ssa.Const); ok && c.Value == nil && !c.IsNil() {
+		if _, ok := c.Type().Underlying().(*types.Struct); ok {
+			return &StructLiteral{fields: map[string]ssa.Value{}, unknown: map[string]bool{}}, true
+		}
+	}
+
+	alloc, ok := value.(*ssa.Alloc)
+	if !ok {
+		return nil, false
+	}
+	structType, ok := alloc.Type().Underlying().(*types.Pointer).Elem().Underlying().(*types.Struct)
+	if !ok {
+		return nil, false
+	}
+
+	literal := &StructLiteral{
+		Alloc:   alloc,
+		fields:  map[string]ssa.Value{},
+		unknown: map[string]bool{},
+	}
+
+	for _, r := range *alloc.Referrers() {
+		switch instr := r.(type) {
+		case *ssa.FieldAddr:
+			name := structType.Field(instr.Field).Name()
+			for _, rr := range *instr.Referrers() {
+				if _, ok := rr.(*ssa.DebugRef); ok || isLoad(rr) {
+					continue
+				}
+				store, ok := rr.(*ssa.Store)
+				if !ok || store.Addr != instr {
+					return nil, false // the address of the field escapes
+				}
+				if _, assigned := literal.fields[name]; assigned {
+					literal.unknown[name] = true
+				}
+				literal.fields[name] = store.Val
+			}
+
+		case *ssa.Store:
+			if instr.Addr != alloc {
+				// The address of the struct is stored elsewhere. Only a pointer field of another literal is
+				// supported, which is folded itself.
+				if _, ok := instr.Addr.(*ssa.FieldAddr); !ok {
+					return nil, false
+				}
+				continue
+			}
+			// Assigning the struct as a whole, e.g. zero initialization. Non-zero values can't be tracked.
+			if c, ok := instr.Val.(*ssa.Const); !ok || c.Value != nil {
+				return nil, false
+			}
+
+		case *ssa.DebugRef:
+			continue
+
+		default:
+			if !isLoad(instr) {
+				return nil, false // the address of the struct escapes, e.g. to a function filling in its fields
+			}
+		}
+	}
+
+	return literal, true
+}
+
+// isLoad returns true if instr loads the value pointed to by its operand
+func isLoad(instr ssa.Instruction) bool {
+	load, ok := instr.(*ssa.UnOp)
+	return ok && load.Op == token.MUL
+}
+
+// IsSet returns true if the field is explicitly assigned in the composite literal
+func (s *StructLiteral) IsSet(name string) bool {
+	_, ok := s.fields[name]
+	return ok
+}
+
+// ConstField returns the constant value of a field, or false if it is not a known constant
+//
+// Unassigned fields are returned as a nil *ssa.Const, use the typed accessors to read their zero value.
+func (s *StructLiteral) ConstField(name string) (*ssa.Const, bool) {
+	if s.unknown[name] {
+		return nil, false
+	}
+
+	value, ok := s.fields[name]
+	if !ok {
+		return nil, true
+	}
+
+	c, ok := value.(*ssa.Const)
+	return c, ok
+}
+
+// IntField returns the value of an integer field (including time.Duration)
+func (s *StructLiteral) IntField(name string) (int64, bool) {
+	c, ok := s.ConstField(name)
+	if !ok || c == nil || c.Value == nil {
+		return 0, ok
+	}
+	v, exact := constant.Int64Val(constant.ToInt(c.Value))
+	return v, exact
+}
+
+// FloatField returns the value of a floating point field
+func (s *StructLiteral) FloatField(name string) (float64, bool) {
+	c, ok := s.ConstField(name)
+	if !ok || c == nil || c.Value == nil {
+		return 0, ok
+	}
+	v, _ := constant.Float64Val(constant.ToFloat(c.Value))
+	return v, true
+}
+
+// StringField returns the value of a string field
+func (s *StructLiteral) StringField(name string) (string, bool) {
+	c, ok := s.ConstField(name)
+	if !ok || c == nil || c.Value == nil {
+		return "", ok
+	}
+	if c.Value.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(c.Value), true
+}
+
+// StructField returns the composite literal assigned to a struct or pointer-to-struct field
+//
+// Returns a nil StructLiteral for unassigned fields.
+func (s *StructLiteral) StructField(name string) (*StructLiteral, bool) {
+	if s.unknown[name] {
+		return nil, false
+	}
+
+	value, ok := s.fields[name]
+	if !ok || IsNilConst(value) {
+		return nil, true
+	}
+
+	return FoldStruct(value)
+}
+
+// ConstString returns the value of a constant string, or false if value is not a constant string
+func ConstString(value ssa.Value) (string, bool) {
+	c, ok := value.(*ssa.Const)
+	if !ok || c.Value == nil || c.Value.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(c.Value), true
+}
+
+// ConstInt returns the value of a constant integer, or false if value is not a constant integer
+func ConstInt(value ssa.Value) (int64, bool) {
+	c, ok := value.(*ssa.Const)
+	if !ok || c.Value == nil || c.Value.Kind() != constant.Int {
+		return 0, false
+	}
+	return constant.Int64Val(c.Value)
+}
@@ -0,0 +1,23 @@
+package analysis
+
+import "golang.org/x/tools/go/ssa"
+
+// Entrypoints are the functions through which a program is entered, grouped by who calls them
+type Entrypoints struct {
+	// Roots are the functions run outside of Cadence, i.e. the main and init functions of main packages, the init,
+	// exported and test functions of library packages, and the constructors provided to Fx
+	Roots []*ssa.Function
+
+	Workflows     []*ssa.Function
+	Activities    []*ssa.Function
+	QueryHandlers []*ssa.Function
+
+	// CandidateWorkflows are exported functions of library packages matching the signature of workflow functions,
+	// which may be registered by the programs importing them. They are only collected for packages without a main
+	// function and without registered workflows, and are checked like workflows without being registrations.
+	CandidateWorkflows []*ssa.Function
+
+	// RegisteredNames are the names workflows and activities are registered under, if set explicitly by the
+	// registration options. Cadence derives the name of other functions from their fully qualified name.
+	RegisteredNames map[*ssa.Function]string
+}
@@ -0,0 +1,216 @@
+package analysis
+
+import (
+	"go/types"
+	"reflect"
+	"strings"
+)
+
+// customJSONMethods are methods indicating that a type controls its own encoding
+var customJSONMethods = []string{
+	"MarshalJSON",
+	"UnmarshalJSON",
+	"MarshalText",
+	"UnmarshalText",
+}
+
+// HasCustomJSONEncoding returns true if typ, or a pointer to it, controls its own JSON encoding
+func HasCustomJSONEncoding(typ types.Type) bool {
+	methods := types.NewMethodSet(types.NewPointer(typ))
+	for _, name := range customJSONMethods {
+		if methods.Lookup(nil, name) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// JSONField is a field of a struct encoded by the JSON encoder
+type JSONField struct {
+	// Name is the key of the field in the encoded object
+	Name  string
+	Field *types.Var
+}
+
+// JSONFields returns the fields of t encoded by the JSON encoder, i.e. its exported fields and the fields promoted
+// from embedded structs, which are encoded even if the embedded struct type is unexported
+func JSONFields(t *types.Struct) []JSONField {
+	var result []JSONField
+	collectJSONFields(t, map[types.Type]bool{}, &result)
+	return result
+}
+
+func collectJSONFields(t *types.Struct, embedded map[types.Type]bool, result *[]JSONField) {
+	for i := 0; i < t.NumFields(); i++ {
+		field := t.Field(i)
+		tag := reflect.StructTag(t.Tag(i)).Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+
+		if field.Anonymous() && name == "" {
+			typ := field.Type()
+			if pointer, ok := typ.(*types.Pointer); ok {
+				typ = pointer.Elem()
+			}
+			if s, ok := typ.Underlying().(*types.Struct); ok {
+				if !embedded[typ] {
+					embedded[typ] = true
+					collectJSONFields(s, embedded, result)
+				}
+				continue
+			}
+		}
+
+		if !field.Exported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name()
+		}
+		*result = append(*result, JSONField{Name: name, Field: field})
+	}
+}
+
+// JSONCompatible returns true if a value of type from, encoded by the JSON encoder, can be decoded into a value of
+// type to
+//
+// Types are compared by the shape of their encoding rather than their identity, e.g. an int may be decoded into an
+// int64 or a float64, but a float64 not into an int, and a struct into another struct with fields of the same names.
+// Fields missing on either side are ignored by the decoder, but structs sharing no fields are not compatible. Types
+// with custom encodings and interfaces are assumed to be compatible.
+func JSONCompatible(from types.Type, to types.Type) bool {
+	return jsonCompatible(from, to, map[[2]types.Type]bool{})
+}
+
+func jsonCompatible(from types.Type, to types.Type, seen map[[2]types.Type]bool) bool {
+	from, to = derefAll(from), derefAll(to)
+
+	pair := [2]types.Type{from, to}
+	if seen[pair] {
+		return true // recursive types, already being compared
+	}
+	seen[pair] = true
+
+	if HasCustomJSONEncoding(from) || HasCustomJSONEncoding(to) {
+		return true
+	}
+	if types.IsInterface(from) || types.IsInterface(to) {
+		return true
+	}
+
+	fromKind, toKind := jsonKind(from), jsonKind(to)
+	if fromKind == "integer" && toKind == "float" {
+		return true // integers decode into floats, but fractions fail to decode into integers
+	}
+	if fromKind != toKind {
+		return false
+	}
+
+	switch fromKind {
+	case "array":
+		return jsonCompatible(elem(from), elem(to), seen)
+	case "object":
+		return objectsCompatible(from.Underlying(), to.Underlying(), seen)
+	default:
+		return true
+	}
+}
+
+func objectsCompatible(from types.Type, to types.Type, seen map[[2]types.Type]bool) bool {
+	fromStruct, fromIsStruct := from.(*types.Struct)
+	toStruct, toIsStruct := to.(*types.Struct)
+
+	switch {
+	case fromIsStruct && toIsStruct:
+		toFields := map[string]JSONField{}
+		for _, field := range JSONFields(toStruct) {
+			// the decoder matches keys case-insensitively
+			toFields[strings.ToLower(field.Name)] = field
+		}
+
+		fromFields := JSONFields(fromStruct)
+		shared := 0
+		for _, field := range fromFields {
+			target, ok := toFields[strings.ToLower(field.Name)]
+			if !ok {
+				continue
+			}
+			shared++
+			if !jsonCompatible(field.Field.Type(), target.Field.Type(), seen) {
+				return false
+			}
+		}
+		return shared > 0 || len(fromFields) == 0
+
+	case !fromIsStruct && !toIsStruct:
+		return jsonCompatible(from.(*types.Map).Elem(), to.(*types.Map).Elem(), seen)
+
+	case fromIsStruct:
+		for _, field := range JSONFields(fromStruct) {
+			if !jsonCompatible(field.Field.Type(), to.(*types.Map).Elem(), seen) {
+				return false
+			}
+		}
+		return true
+
+	default:
+		// the keys of the map are only known at runtime
+		return true
+	}
+}
+
+// jsonKind returns the kind of JSON value typ is encoded as. Numbers are split into integers and floats, as a float
+// with a fraction fails to decode into an integer.
+func jsonKind(typ types.Type) string {
+	switch t := typ.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case t.Info()&types.IsBoolean != 0:
+			return "boolean"
+		case t.Info()&types.IsInteger != 0:
+			return "integer" // including unsigned integers
+		case t.Info()&types.IsFloat != 0:
+			return "float"
+		case t.Info()&types.IsString != 0:
+			return "string"
+		}
+	case *types.Slice:
+		if isByte(t.Elem()) {
+			return "string" // base64 encoded
+		}
+		return "array"
+	case *types.Array:
+		return "array"
+	case *types.Struct, *types.Map:
+		return "object"
+	}
+	return "unknown"
+}
+
+func elem(typ types.Type) types.Type {
+	switch t := typ.Underlying().(type) {
+	case *types.Slice:
+		return t.Elem()
+	case *types.Array:
+		return t.Elem()
+	}
+	return typ
+}
+
+func isByte(typ types.Type) bool {
+	basic, ok := typ.Underlying().(*types.Basic)
+	return ok && basic.Kind() == types.Byte
+}
+
+// derefAll strips all pointers from typ, which the encoder follows and the decoder allocates
+func derefAll(typ types.Type) types.Type {
+	for {
+		pointer, ok := typ.Underlying().(*types.Pointer)
+		if !ok {
+			return typ
+		}
+		typ = pointer.Elem()
+	}
+}
@@ -0,0 +1,201 @@
+package analysis
+
+import (
+	"go/token"
+	"golang.org/x/tools/go/ssa"
+)
+
+// Loop is a natural loop of a function, i.e. the blocks of the function which can return to the loop header
+type Loop struct {
+	Header *ssa.BasicBlock
+	Body   map[*ssa.BasicBlock]bool
+}
+
+// FindLoops returns the loops of f, merging loops with a shared header, in the order of their headers
+func FindLoops(f *ssa.Function) []*Loop {
+	var result []*Loop
+	for _, header := range f.Blocks {
+		var l *Loop
+		for _, pred := range header.Preds {
+			if !header.Dominates(pred) {
+				continue // not a back edge
+			}
+
+			if l == nil {
+				l = &Loop{Header: header, Body: map[*ssa.BasicBlock]bool{header: true}}
+				result = append(result, l)
+			}
+			l.add(pred)
+		}
+	}
+	return result
+}
+
+// add adds b, and the blocks reaching b without passing through the loop header, to the loop
+func (l *Loop) add(b *ssa.BasicBlock) {
+	if l.Body[b] {
+		return
+	}
+	l.Body[b] = true
+	for _, pred := range b.Preds {
+		l.add(pred)
+	}
+}
+
+// Blocks returns the blocks of the loop, in the order of the function
+func (l *Loop) Blocks() []*ssa.BasicBlock {
+	var result []*ssa.BasicBlock
+	for _, b := range l.Header.Parent().Blocks {
+		if l.Body[b] {
+			result = append(result, b)
+		}
+	}
+	return result
+}
+
+// Exits returns the blocks outside the loop entered when leaving it
+func (l *Loop) Exits() []*ssa.BasicBlock {
+	var result []*ssa.BasicBlock
+	for _, b := range l.Blocks() {
+		for _, succ := range b.Succs {
+			if !l.Body[succ] {
+				result = append(result, succ)
+			}
+		}
+	}
+	return result
+}
+
+// Position returns the first instruction of the loop header with a position, falling back to the first such
+// instruction of the loop. Phis are skipped, as their position is the declaration of the variable.
+func (l *Loop) Position() ssa.Instruction {
+	for _, b := range append([]*ssa.BasicBlock{l.Header}, l.Blocks()...) {
+		for _, instr := range b.Instrs {
+			if _, ok := instr.(*ssa.Phi); !ok && instr.Pos() != token.NoPos {
+				return instr
+			}
+		}
+	}
+	return l.Header.Instrs[0]
+}
+
+// RangesOverMapOrString returns true for range loops over a map or string
+func (l *Loop) RangesOverMapOrString() bool {
+	for _, b := range l.Blocks() {
+		for _, instr := range b.Instrs {
+			if _, ok := instr.(*ssa.Next); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Counted returns true if the loop is left by comparing an induction variable, e.g. a counter or the index of a
+// range loop over a slice
+func (l *Loop) Counted() bool {
+	for _, cond := range l.exitComparisons() {
+		if l.isInductionVariable(cond.X) || l.isInductionVariable(cond.Y) {
+			return true
+		}
+	}
+	return false
+}
+
+// ConstantBound returns true if the loop is left by comparing an induction variable against a constant
+func (l *Loop) ConstantBound() bool {
+	return l.boundedBy(func(bound ssa.Value) bool {
+		_, ok := bound.(*ssa.Const)
+		return ok
+	})
+}
+
+// IteratesCollection returns true for range loops over a slice, map or string, and loops comparing an induction
+// variable against the length of a value
+func (l *Loop) IteratesCollection() bool {
+	return l.RangesOverMapOrString() || l.boundedBy(func(bound ssa.Value) bool {
+		call, ok := bound.(*ssa.Call)
+		if !ok {
+			return false
+		}
+		builtin, ok := call.Call.Value.(*ssa.Builtin)
+		return ok && builtin.Name() == "len"
+	})
+}
+
+// boundedBy returns true if the loop is left by comparing an induction variable against a value matching isBound
+func (l *Loop) boundedBy(isBound func(bound ssa.Value) bool) bool {
+	for _, cond := range l.exitComparisons() {
+		if (l.isInductionVariable(cond.X) && isBound(cond.Y)) || (isBound(cond.X) && l.isInductionVariable(cond.Y)) {
+			return true
+		}
+	}
+	return false
+}
+
+// exitComparisons returns the ordering comparisons of the branches leaving the loop
+func (l *Loop) exitComparisons() []*ssa.BinOp {
+	var result []*ssa.BinOp
+	for _, b := range l.Blocks() {
+		branch, ok := b.Instrs[len(b.Instrs)-1].(*ssa.If)
+		if !ok || (l.Body[b.Succs[0]] && l.Body[b.Succs[1]]) {
+			continue // not an exit of the loop
+		}
+
+		cond, ok := branch.Cond.(*ssa.BinOp)
+		if ok && isOrdering(cond.Op) {
+			result = append(result, cond)
+		}
+	}
+	return result
+}
+
+// isInductionVariable returns true for a phi of the loop header stepped by a constant on every iteration, or the
+// phi plus a constant
+func (l *Loop) isInductionVariable(v ssa.Value) bool {
+	if step, ok := v.(*ssa.BinOp); ok {
+		if phi, ok := steppedPhi(step); ok {
+			v = phi
+		}
+	}
+
+	phi, ok := v.(*ssa.Phi)
+	if !ok || phi.Block() != l.Header {
+		return false
+	}
+
+	for i, edge := range phi.Edges {
+		if !l.Body[l.Header.Preds[i]] {
+			continue // initial value
+		}
+		step, ok := edge.(*ssa.BinOp)
+		if !ok {
+			return false
+		}
+		if stepped, ok := steppedPhi(step); !ok || stepped != phi {
+			return false
+		}
+	}
+	return true
+}
+
+// steppedPhi returns the phi of a binary operation adding or subtracting a constant from a phi
+func steppedPhi(step *ssa.BinOp) (*ssa.Phi, bool) {
+	if step.Op != token.ADD && step.Op != token.SUB {
+		return nil, false
+	}
+	if _, ok := step.Y.(*ssa.Const); !ok {
+		return nil, false
+	}
+	phi, ok := step.X.(*ssa.Phi)
+	return phi, ok
+}
+
+func isOrdering(op token.Token) bool {
+	switch op {
+	case token.LSS, token.LEQ, token.GTR, token.GEQ, token.NEQ:
+		return true
+	default:
+		return false
+	}
+}
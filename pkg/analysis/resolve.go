@@ -0,0 +1,143 @@
+package analysis
+
+import (
+	"fmt"
+	"go/token"
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/ssa"
+	"reflect"
+)
+
+// TODO document this as it is non-trivial
+// TODO current implementation is filled with hacks and does just enough (and makes a lot of assumptions) to
+// get the current examples to work. This should either be a proper DFA or we should find another approach for
+// identifying entrypoints
+func ResolveFunctions(value ssa.Value, callGraph *callgraph.Graph, seen map[ssa.Value]bool) ([]*ssa.Function, error) {
+
+	if seen[value] {
+		return nil, fmt.Errorf("breaking circle")
+	}
+
+	seen[value] = true
+
+	switch v := value.(type) {
+	case *ssa.MakeInterface:
+		var operands []*ssa.Value
+		operands = v.Operands(operands)
+		return ResolveFunctions(*operands[0], callGraph, seen)
+
+	case *ssa.Function:
+		return []*ssa.Function{v}, nil
+
+	case *ssa.Phi:
+		var result []*ssa.Function
+		for _, e := range v.Edges {
+			fs, err := ResolveFunctions(e, callGraph, seen)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, fs...)
+		}
+		return result, nil
+
+	case *ssa.UnOp:
+		// NOT SUB ARROW MUL XOR
+		switch v.Op {
+		case token.MUL:
+			return ResolveFunctions(v.X, callGraph, seen)
+		default:
+			return nil, fmt.Errorf("unsupported SSA value type %s[%s] encountered when unpacking SSA value", reflect.TypeOf(v), v.Op)
+		}
+
+	case *ssa.Parameter:
+		idx, err := getParamIndex(v, v.Parent())
+		if err != nil {
+			return nil, err
+		}
+
+		node := callGraph.Nodes[v.Parent()]
+		if node == nil {
+			return nil, fmt.Errorf("function %s is not in the call graph", v.Parent().RelString(nil))
+		}
+
+		var result []*ssa.Function
+		for _, edge := range node.In {
+			argIdx := idx
+			if v.Parent().Signature.Recv() != nil && !edge.Site.Common().IsInvoke() {
+				argIdx++ // static calls to methods pass the receiver as the first argument
+			}
+
+			fs, err := ResolveFunctions(edge.Site.Common().Args[argIdx], callGraph, seen)
+			if err != nil {
+				return nil, err
+			}
+
+			result = append(result, fs...)
+		}
+
+		return result, nil
+
+	case *ssa.MakeClosure:
+		return ResolveFunctions(v.Fn, callGraph, seen)
+
+	case *ssa.Slice:
+		// Encountered for fx.Provide which takes a slice, follow to underlying allocation
+		return ResolveFunctions(v.X, callGraph, seen)
+
+	case *ssa.Alloc:
+		var result []*ssa.Function
+		for _, r := range *v.Referrers() {
+			if _, ok := r.(*ssa.Slice); ok {
+				continue // HACK bypass circular dependency - this is not a solid analysis FYI
+			}
+
+			if _, ok := r.(ssa.Value); !ok {
+				continue // must be a value, this is not good either
+			}
+
+			fns, err := ResolveFunctions(r.(ssa.Value), callGraph, seen)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, fns...)
+		}
+		return result, nil
+
+	case *ssa.IndexAddr:
+		var result []*ssa.Function
+		for _, r := range *v.Referrers() {
+			if vv, ok := r.(*ssa.Store); ok {
+				fns, err := ResolveFunctions(vv.Val, callGraph, seen)
+				if err != nil {
+					return nil, err
+				}
+				result = append(result, fns...)
+				continue
+			}
+
+			if _, ok := r.(ssa.Value); !ok {
+				continue // must be a value, this is not good either
+			}
+
+			fns, err := ResolveFunctions(r.(ssa.Value), callGraph, seen)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, fns...)
+		}
+		return result, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported SSA value type %s encountered when unpacking SSA value", reflect.TypeOf(v))
+	}
+}
+
+func getParamIndex(param *ssa.Parameter, f *ssa.Function) (int, error) {
+	for i := 0; i < f.Signature.Params().Len(); i++ {
+		if f.Signature.Params().At(i).Name() == param.Name() {
+			return i, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unable to find param in function")
+}
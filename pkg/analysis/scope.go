@@ -0,0 +1,43 @@
+package analysis
+
+import (
+	"github.com/sema/cadencecheck/pkg/entities"
+	"golang.org/x/tools/go/ssa"
+	"strings"
+)
+
+const (
+	_cadencePackagePrefix = "go.uber.org/cadence"
+)
+
+// IsLibraryFunction returns true for functions defined in the standard library, in vendored packages or in the
+// Cadence client itself
+//
+// Synthetic functions without a package (e.g. wrappers) are not considered library functions.
+func IsLibraryFunction(f *ssa.Function) bool {
+	if f == nil || f.Pkg == nil {
+		return false
+	}
+
+	return IsLibraryPackage(f.Pkg.Pkg.Path())
+}
+
+// IsLibraryPackage returns true if pkgPath is part of the standard library, vendored, or part of the Cadence client
+func IsLibraryPackage(pkgPath string) bool {
+	if strings.HasPrefix(pkgPath, "vendor/") || strings.Contains(pkgPath, "/vendor/") {
+		return true
+	}
+
+	if IsCadencePackage(pkgPath) {
+		return true
+	}
+
+	// Standard library packages do not contain a domain name as the first path element
+	firstElement := strings.Split(pkgPath, "/")[0]
+	return !strings.Contains(firstElement, ".")
+}
+
+// IsCadencePackage returns true if pkgPath is part of the Cadence client
+func IsCadencePackage(pkgPath string) bool {
+	return strings.HasPrefix(entities.StripVendor(pkgPath), _cadencePackagePrefix)
+}
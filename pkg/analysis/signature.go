@@ -0,0 +1,118 @@
+package analysis
+
+import (
+	"errors"
+	"fmt"
+	"github.com/sema/cadencecheck/pkg/entities"
+	"go/types"
+	"golang.org/x/tools/go/ssa"
+	"reflect"
+	"strings"
+)
+
+var (
+	errIgnoreReceiver = errors.New("ignored receiver type")
+)
+
+func receiverTypeSignature(typ types.Type) (pkgName string, typeName string, err error) {
+	switch t := typ.(type) {
+	case *types.Named:
+		if t.Obj().Pkg() == nil {
+			// predeclared types, e.g. the error interface
+			return "", "", errIgnoreReceiver
+		}
+		return t.Obj().Pkg().Path(), t.Obj().Name(), nil
+	case *types.Pointer:
+		return receiverTypeSignature(t.Elem())
+	case *types.Struct:
+		// ignore structs - these usually represent bound methods and the call graph will point
+		// to the method itself
+		return "", "", errIgnoreReceiver
+	default:
+		return "", "", fmt.Errorf("unsupported receiver encountered with type %s: %s",
+			reflect.TypeOf(typ).Name(),
+			types.TypeString(typ, types.RelativeTo(nil)))
+	}
+}
+
+// FunctionSignature returns the FunctionPattern matching f
+//
+// An empty FunctionPattern is returned for functions which can't be matched by a pattern, e.g. anonymous functions.
+func FunctionSignature(f ssa.Function) (signature entities.FunctionPattern, err error) {
+	// Anonymous?
+	if f.Parent() != nil {
+		// No support for matching anonymous functions
+		return entities.FunctionPattern{}, nil
+	}
+
+	var recvType types.Type
+
+	if recv := f.Signature.Recv(); recv != nil {
+		// Method (declared or wrapper)?
+		recvType = recv.Type()
+	} else if f.Synthetic == "thunk" {
+		// Thunk?
+		// NOTE: other synthetic cases have f.Signature.Recv, and are thus handled by the previous case
+		recvType = f.Signature.Params().At(0).Type()
+	} else if len(f.FreeVars) == 1 && strings.HasSuffix(f.Name(), "$bound") {
+		// Bound?
+		recvType = f.FreeVars[0].Type()
+	}
+
+	if recvType != nil {
+		pkgName, typeName, err := receiverTypeSignature(recvType)
+		if err != nil {
+			if err == errIgnoreReceiver {
+				return entities.FunctionPattern{}, nil
+			}
+			return entities.FunctionPattern{}, err
+		}
+
+		return entities.FunctionPattern{
+			Package: entities.StripVendor(pkgName),
+			Type:    typeName,
+			Method:  f.Name(),
+		}, nil
+	}
+
+	// Package-level function?
+	// Prefix with package name for cross-package references only.
+	if f.Pkg != nil {
+		return entities.FunctionPattern{
+			Package: entities.StripVendor(f.Pkg.Pkg.Path()),
+			Type:    "",
+			Method:  f.Name(),
+		}, nil
+	}
+
+	return entities.FunctionPattern{}, fmt.Errorf("unable to create signature for function")
+}
+
+// CallSignature returns the FunctionPattern matching the function called by a call instruction
+//
+// Unlike the call graph, which points to the concrete implementations of a method called through an interface, the
+// pattern for an interface method invocation matches the interface type itself, e.g. internal.Future.Get.
+func CallSignature(call *ssa.CallCommon) (signature entities.FunctionPattern, err error) {
+	if call.IsInvoke() {
+		pkgName, typeName, err := receiverTypeSignature(call.Value.Type())
+		if err != nil {
+			if err == errIgnoreReceiver {
+				return entities.FunctionPattern{}, nil
+			}
+			return entities.FunctionPattern{}, err
+		}
+
+		return entities.FunctionPattern{
+			Package: entities.StripVendor(pkgName),
+			Type:    typeName,
+			Method:  call.Method.Name(),
+		}, nil
+	}
+
+	if callee := call.StaticCallee(); callee != nil {
+		return FunctionSignature(*callee)
+	}
+
+	// Dynamic call of a function value - no way to tell what is being called without resolving the value
+	return entities.FunctionPattern{}, nil
+}
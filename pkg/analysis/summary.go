@@ -0,0 +1,88 @@
+package analysis
+
+import (
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/ssa"
+)
+
+// CallSummary records which functions make a call matching a predicate, directly or through the application
+// functions they call
+type CallSummary struct {
+	callGraph *callgraph.Graph
+	matches   func(call *ssa.Call) bool
+	results   map[*ssa.Function]bool
+	// inProgress maps the functions being summarized to their depth in the stack of summarized functions
+	inProgress map[*ssa.Function]int
+	// lowest is the lowest depth of an in-progress function the current summary depended on through recursion
+	lowest int
+}
+
+func NewCallSummary(callGraph *callgraph.Graph, matches func(call *ssa.Call) bool) *CallSummary {
+	return &CallSummary{
+		callGraph:  callGraph,
+		matches:    matches,
+		results:    map[*ssa.Function]bool{},
+		inProgress: map[*ssa.Function]int{},
+	}
+}
+
+// Call returns true if call matches, or calls an application function which makes a matching call
+func (s *CallSummary) Call(call *ssa.Call) bool {
+	if s.matches(call) {
+		return true
+	}
+
+	node := s.callGraph.Nodes[call.Parent()]
+	if node == nil {
+		return false
+	}
+	for _, edge := range node.Out {
+		if edge.Site == call && !IsLibraryFunction(edge.Callee.Func) && s.Function(edge.Callee.Func) {
+			return true
+		}
+	}
+	return false
+}
+
+// Function returns true if f makes a matching call, directly or through the application functions it calls
+func (s *CallSummary) Function(f *ssa.Function) bool {
+	if result, ok := s.results[f]; ok {
+		return result
+	}
+	if depth, ok := s.inProgress[f]; ok {
+		// recursion, the result of f is not known yet
+		if depth < s.lowest {
+			s.lowest = depth
+		}
+		return false
+	}
+
+	depth := len(s.inProgress)
+	s.inProgress[f] = depth
+	outer := s.lowest
+	s.lowest = depth
+
+	result := s.Blocks(f.Blocks)
+	delete(s.inProgress, f)
+
+	// A negative result depending on a function further up the stack may change once that function is summarized
+	if result || s.lowest >= depth {
+		s.results[f] = result
+	}
+	if outer < s.lowest {
+		s.lowest = outer
+	}
+	return result
+}
+
+// Blocks returns true if some call of the given blocks makes a matching call
+func (s *CallSummary) Blocks(blocks []*ssa.BasicBlock) bool {
+	for _, block := range blocks {
+		for _, instr := range block.Instrs {
+			if call, ok := instr.(*ssa.Call); ok && s.Call(call) {
+				return true
+			}
+		}
+	}
+	return false
+}
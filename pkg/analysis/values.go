@@ -0,0 +1,82 @@
+package analysis
+
+import (
+	"go/types"
+	"golang.org/x/tools/go/ssa"
+)
+
+// VariadicArguments unpacks the individual arguments passed to a variadic parameter
+//
+// The SSA form of a variadic call f(a, b) allocates an array, stores each argument in the array, and passes a slice
+// of the array to f. VariadicArguments returns false if value does not follow this pattern, e.g. if an existing
+// slice is passed as f(args...).
+func VariadicArguments(value ssa.Value) ([]ssa.Value, bool) {
+	switch v := value.(type) {
+	case *ssa.Const:
+		if v.IsNil() {
+			return nil, true // no arguments
+		}
+		return nil, false
+
+	case *ssa.Slice:
+		alloc, ok := v.X.(*ssa.Alloc)
+		if !ok {
+			return nil, false
+		}
+		array, ok := alloc.Type().Underlying().(*types.Pointer).Elem().Underlying().(*types.Array)
+		if !ok {
+			return nil, false
+		}
+
+		args := make([]ssa.Value, array.Len())
+		for _, r := range *alloc.Referrers() {
+			indexAddr, ok := r.(*ssa.IndexAddr)
+			if !ok {
+				continue
+			}
+			index, ok := indexAddr.Index.(*ssa.Const)
+			if !ok {
+				return nil, false
+			}
+
+			for _, rr := range *indexAddr.Referrers() {
+				if store, ok := rr.(*ssa.Store); ok && store.Addr == indexAddr {
+					args[index.Int64()] = store.Val
+				}
+			}
+		}
+
+		for _, arg := range args {
+			if arg == nil {
+				return nil, false
+			}
+		}
+		return args, true
+
+	default:
+		return nil, false
+	}
+}
+
+// ConcreteType returns the type of the value wrapped in an interface value, or false if the type is unknown
+//
+// Nil constants have no concrete type and are reported as unknown.
+func ConcreteType(value ssa.Value) (types.Type, bool) {
+	switch v := value.(type) {
+	case *ssa.MakeInterface:
+		return v.X.Type(), true
+	case *ssa.Const:
+		return nil, false
+	default:
+		if types.IsInterface(v.Type()) {
+			return nil, false
+		}
+		return v.Type(), true
+	}
+}
+
+// IsNilConst returns true if value is the constant nil
+func IsNilConst(value ssa.Value) bool {
+	c, ok := value.(*ssa.Const)
+	return ok && c.IsNil()
+}
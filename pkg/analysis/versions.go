@@ -0,0 +1,184 @@
+package analysis
+
+import (
+	"github.com/sema/cadencecheck/pkg/entities"
+	"go/token"
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/ssa"
+)
+
+// GetVersionPattern matches calls to workflow.GetVersion
+var GetVersionPattern = entities.FunctionPattern{
+	Package: "go.uber.org/cadence/workflow",
+	Type:    "",
+	Method:  "GetVersion",
+}
+
+// negated maps comparison operators to the operator holding when the comparison is false
+var negated = map[token.Token]token.Token{
+	token.EQL: token.NEQ,
+	token.NEQ: token.EQL,
+	token.LSS: token.GEQ,
+	token.GEQ: token.LSS,
+	token.GTR: token.LEQ,
+	token.LEQ: token.GTR,
+}
+
+// swapped maps comparison operators to the operator holding when the operands are swapped
+var swapped = map[token.Token]token.Token{
+	token.EQL: token.EQL,
+	token.NEQ: token.NEQ,
+	token.LSS: token.GTR,
+	token.GTR: token.LSS,
+	token.LEQ: token.GEQ,
+	token.GEQ: token.LEQ,
+}
+
+// PruneOldVersions removes the calls from callGraph which only run for older versions of a GetVersion change, i.e.
+// calls guarded by a comparison of the version which does not hold for the newest version. Functions only reachable
+// through such calls are no longer reached when traversing the call graph.
+func PruneOldVersions(callGraph *callgraph.Graph) {
+	newest := map[ssa.CallInstruction]bool{}
+	runsForNewest := func(edge *callgraph.Edge) bool {
+		if edge.Site == nil {
+			return true
+		}
+		if _, ok := newest[edge.Site]; !ok {
+			newest[edge.Site] = entities.HoldForNewest(VersionGuards(edge.Site))
+		}
+		return newest[edge.Site]
+	}
+
+	for _, node := range callGraph.Nodes {
+		node.Out = filterEdges(node.Out, runsForNewest)
+		node.In = filterEdges(node.In, runsForNewest)
+	}
+}
+
+func filterEdges(edges []*callgraph.Edge, keep func(edge *callgraph.Edge) bool) []*callgraph.Edge {
+	var result []*callgraph.Edge
+	for _, edge := range edges {
+		if keep(edge) {
+			result = append(result, edge)
+		}
+	}
+	return result
+}
+
+// PathVersionGuards returns the version guards dominating instr and each call site of stackTrace leading to it
+func PathVersionGuards(instr ssa.Instruction, stackTrace []*callgraph.Edge) []entities.VersionGuard {
+	var result []entities.VersionGuard
+	for _, edge := range stackTrace {
+		if edge.Site != nil {
+			result = append(result, VersionGuards(edge.Site)...)
+		}
+	}
+	if instr != nil {
+		result = append(result, VersionGuards(instr)...)
+	}
+	return result
+}
+
+// VersionGuards returns the version guards dominating instr within its function, outermost first
+func VersionGuards(instr ssa.Instruction) []entities.VersionGuard {
+	block := instr.Block()
+	if block == nil {
+		return nil
+	}
+
+	var result []entities.VersionGuard
+	for dom := block.Idom(); dom != nil; dom = dom.Idom() {
+		guard, ok := versionGuard(dom, block)
+		if ok {
+			result = append([]entities.VersionGuard{guard}, result...)
+		}
+	}
+	return result
+}
+
+// versionGuard returns the guard established by the branch at the end of dom, if only one of its successors
+// dominates block
+func versionGuard(dom *ssa.BasicBlock, block *ssa.BasicBlock) (entities.VersionGuard, bool) {
+	if len(dom.Instrs) == 0 {
+		return entities.VersionGuard{}, false
+	}
+
+	branch, ok := dom.Instrs[len(dom.Instrs)-1].(*ssa.If)
+	if !ok {
+		return entities.VersionGuard{}, false
+	}
+
+	taken := -1
+	for i, succ := range dom.Succs {
+		if succ.Dominates(block) {
+			taken = i
+		}
+	}
+	if taken < 0 || dom.Succs[0] == dom.Succs[1] {
+		return entities.VersionGuard{}, false
+	}
+
+	guard, ok := comparison(branch.Cond)
+	if !ok {
+		return entities.VersionGuard{}, false
+	}
+
+	if taken == 1 {
+		guard.Op = negated[guard.Op]
+	}
+	return guard, true
+}
+
+// comparison returns the guard for a comparison of a GetVersion result against a constant
+func comparison(cond ssa.Value) (entities.VersionGuard, bool) {
+	binOp, ok := cond.(*ssa.BinOp)
+	if !ok {
+		return entities.VersionGuard{}, false
+	}
+	if _, ok := negated[binOp.Op]; !ok {
+		return entities.VersionGuard{}, false
+	}
+
+	op := binOp.Op
+	call, other := getVersionCall(binOp.X), binOp.Y
+	if call == nil {
+		op = swapped[op]
+		call, other = getVersionCall(binOp.Y), binOp.X
+	}
+	if call == nil {
+		return entities.VersionGuard{}, false
+	}
+
+	version, ok := ConstInt(other)
+	if !ok {
+		return entities.VersionGuard{}, false
+	}
+
+	args := call.Call.Args
+	changeID, ok := ConstString(args[1])
+	if !ok {
+		return entities.VersionGuard{}, false
+	}
+
+	guard := entities.VersionGuard{
+		ChangeID: changeID,
+		Op:       op,
+		Version:  version,
+	}
+	guard.MaxSupported, guard.HasMaxSupported = ConstInt(args[3])
+	return guard, true
+}
+
+// getVersionCall returns value as a call to workflow.GetVersion, or nil if it is not one
+func getVersionCall(value ssa.Value) *ssa.Call {
+	call, ok := value.(*ssa.Call)
+	if !ok {
+		return nil
+	}
+
+	signature, err := CallSignature(call.Common())
+	if err != nil || signature != GetVersionPattern {
+		return nil
+	}
+	return call
+}
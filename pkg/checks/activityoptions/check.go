@@ -0,0 +1,317 @@
+package activityoptions
+
+import (
+	"fmt"
+	"github.com/sema/cadencecheck/pkg/analysis"
+	"github.com/sema/cadencecheck/pkg/entities"
+	"github.com/sema/cadencecheck/pkg/reporter"
+	"go/token"
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/ssa"
+	"strings"
+)
+
+const (
+	_kindMissingOptions = "ERROR-MISSING-OPTIONS"
+
+	_workflowPackage = "go.uber.org/cadence/workflow"
+)
+
+// requiredOptions maps functions executing activities and child workflows to the function that must have been
+// applied to the workflow.Context passed to them
+var requiredOptions = map[entities.FunctionPattern]entities.FunctionPattern{
+	{
+		Package: _workflowPackage,
+		Type:    "",
+		Method:  "ExecuteActivity",
+	}: {
+		Package: _workflowPackage,
+		Type:    "",
+		Method:  "WithActivityOptions",
+	},
+	{
+		Package: _workflowPackage,
+		Type:    "",
+		Method:  "ExecuteLocalActivity",
+	}: {
+		Package: _workflowPackage,
+		Type:    "",
+		Method:  "WithLocalActivityOptions",
+	},
+	{
+		Package: _workflowPackage,
+		Type:    "",
+		Method:  "ExecuteChildWorkflow",
+	}: {
+		Package: _workflowPackage,
+		Type:    "",
+		Method:  "WithChildOptions",
+	},
+}
+
+// path is a chain of functions a workflow.Context flowed through without options being applied
+type path []*ssa.Function
+
+// Check traces the workflow.Context passed to ExecuteActivity, ExecuteLocalActivity and ExecuteChildWorkflow back
+// to its origin, and reports contexts which may reach the call without the matching options having been applied
+//
+// Contexts are traced within the functions reachable from the workflow. Contexts of unknown origin (e.g. returned
+// by library code) are assumed to be configured.
+type Check struct{}
+
+func New() *Check {
+	return &Check{}
+}
+
+func (c *Check) Check(f *ssa.Function, callGraph *callgraph.Graph, reporter *reporter.TerminalReporter) error {
+	root, ok := callGraph.Nodes[f]
+	if !ok {
+		return fmt.Errorf("could not find callgraph for function %s", reporter.FormatFunction(f))
+	}
+
+	reachable := map[*ssa.Function]bool{}
+	analysis.VisitReachableFunctions(root, func(f *ssa.Function, stackTrace []*callgraph.Edge) {
+		reachable[f] = true
+	})
+
+	var patterns []entities.FunctionPattern
+	for p := range requiredOptions {
+		patterns = append(patterns, p)
+	}
+
+	for _, callSite := range analysis.FindCallSites(root, patterns) {
+		t := tracer{
+			root:      f,
+			callGraph: callGraph,
+			reachable: reachable,
+			options:   requiredOptions[callSite.Signature],
+			seen:      map[ssa.Value]bool{},
+		}
+
+		ctx := callSite.Instruction.Common().Args[0]
+		for _, p := range t.trace(ctx, path{callSite.Instruction.Parent()}) {
+			reporter.InstructionIssue(_kindMissingOptions, fmt.Sprintf(
+				"workflow.Context passed to %s may not have been configured using %s: %s",
+				callSite.Signature.Method, t.options.Method, p),
+				callSite.Instruction, callSite.StackTrace, callSite.VersionGuards())
+		}
+	}
+
+	return nil
+}
+
+// through returns a copy of p extended with f
+func (p path) through(f *ssa.Function) path {
+	return append(append(path{}, p...), f)
+}
+
+func (p path) String() string {
+	var names []string
+	for i := len(p) - 1; i >= 0; i-- {
+		names = append(names, p[i].RelString(nil))
+	}
+
+	return fmt.Sprintf("context flows from the parameter of %s", strings.Join(names, " to "))
+}
+
+type tracer struct {
+	root      *ssa.Function
+	callGraph *callgraph.Graph
+	reachable map[*ssa.Function]bool
+	options   entities.FunctionPattern
+	seen      map[ssa.Value]bool
+}
+
+// trace returns the paths through which value may originate from the workflow's parameters without options applied
+func (t *tracer) trace(value ssa.Value, current path) []path {
+	if t.seen[value] {
+		return nil
+	}
+	t.seen[value] = true
+
+	switch v := value.(type) {
+	case *ssa.Parameter:
+		if v.Parent() == t.root {
+			return []path{current}
+		}
+		return t.traceCallers(v, current)
+
+	case *ssa.FreeVar:
+		return t.traceFreeVar(v, current)
+
+	case *ssa.Phi:
+		var result []path
+		for _, edge := range v.Edges {
+			result = append(result, t.trace(edge, current)...)
+		}
+		return result
+
+	case *ssa.Extract:
+		return t.trace(v.Tuple, current)
+
+	case *ssa.UnOp:
+		if v.Op != token.MUL {
+			return nil
+		}
+		// load of a captured or address-taken variable, follow the values stored to it which may reach the load
+		var result []path
+		for _, store := range reachingStores(v) {
+			result = append(result, t.trace(store.Val, current)...)
+		}
+		return result
+
+	case *ssa.Call:
+		return t.traceCall(v, current)
+
+	default:
+		return nil // unknown origin
+	}
+}
+
+func (t *tracer) traceCall(call *ssa.Call, current path) []path {
+	signature, err := analysis.CallSignature(&call.Call)
+	if err != nil {
+		return nil
+	}
+
+	if signature == t.options {
+		return nil // options applied
+	}
+
+	// Other workflow functions deriving a new context (e.g. WithValue, WithCancel) carry over the options
+	if signature.Package == _workflowPackage && len(call.Call.Args) > 0 &&
+		analysis.IsWorkflowContext(call.Call.Args[0].Type()) {
+		return t.trace(call.Call.Args[0], current)
+	}
+
+	// Contexts returned by application code are traced through the callee's return statements
+	callee := call.Call.StaticCallee()
+	if callee == nil || analysis.IsLibraryFunction(callee) || !t.reachable[callee] {
+		return nil
+	}
+
+	var result []path
+	for _, block := range callee.Blocks {
+		ret, ok := block.Instrs[len(block.Instrs)-1].(*ssa.Return)
+		if !ok {
+			continue
+		}
+		for _, r := range ret.Results {
+			if analysis.IsWorkflowContext(r.Type()) {
+				result = append(result, t.trace(r, current.through(callee))...)
+			}
+		}
+	}
+	return result
+}
+
+func (t *tracer) traceCallers(param *ssa.Parameter, current path) []path {
+	fn := param.Parent()
+
+	idx := -1
+	for i, p := range fn.Params {
+		if p == param {
+			idx = i
+		}
+	}
+
+	node := t.callGraph.Nodes[fn]
+	if node == nil || idx < 0 {
+		return nil
+	}
+
+	var result []path
+	for _, edge := range node.In {
+		if !t.reachable[edge.Caller.Func] || analysis.IsLibraryFunction(edge.Caller.Func) {
+			continue
+		}
+
+		// Params includes the receiver of methods, which interface invocations pass separately from Args
+		argIdx := idx
+		if edge.Site.Common().IsInvoke() {
+			argIdx--
+		}
+		if argIdx < 0 || argIdx >= len(edge.Site.Common().Args) {
+			continue
+		}
+
+		result = append(result, t.trace(edge.Site.Common().Args[argIdx], current.through(edge.Caller.Func))...)
+	}
+	return result
+}
+
+func (t *tracer) traceFreeVar(freeVar *ssa.FreeVar, current path) []path {
+	fn := freeVar.Parent()
+
+	idx := -1
+	for i, fv := range fn.FreeVars {
+		if fv == freeVar {
+			idx = i
+		}
+	}
+	if idx < 0 || fn.Parent() == nil {
+		return nil
+	}
+
+	// Closures are created by MakeClosure instructions in their parent function
+	var result []path
+	for _, block := range fn.Parent().Blocks {
+		for _, instr := range block.Instrs {
+			closure, ok := instr.(*ssa.MakeClosure)
+			if !ok || closure.Fn != fn {
+				continue
+			}
+			result = append(result, t.trace(closure.Bindings[idx], current.through(fn.Parent()))...)
+		}
+	}
+	return result
+}
+
+// reachingStores returns the stores to the address loaded by load which may be observed by it
+//
+// Stores which are always overwritten by a later store before the load are left out. Loads within closures observe
+// stores made by their parent function, which are all returned.
+func reachingStores(load *ssa.UnOp) []*ssa.Store {
+	var stores []*ssa.Store
+	for _, r := range *load.X.Referrers() {
+		if store, ok := r.(*ssa.Store); ok && store.Addr == load.X {
+			stores = append(stores, store)
+		}
+	}
+
+	// Find the last store on every path to the load
+	var last *ssa.Store
+	for _, store := range stores {
+		if store.Parent() == load.Parent() && dominates(store, load) && (last == nil || dominates(last, store)) {
+			last = store
+		}
+	}
+	if last == nil {
+		return stores
+	}
+
+	var result []*ssa.Store
+	for _, store := range stores {
+		if store == last || store.Parent() != load.Parent() || !dominates(store, last) {
+			result = append(result, store)
+		}
+	}
+	return result
+}
+
+// dominates returns true if a is executed before b on every path to b within their function
+func dominates(a ssa.Instruction, b ssa.Instruction) bool {
+	if a.Block() != b.Block() {
+		return a.Block().Dominates(b.Block())
+	}
+
+	for _, instr := range a.Block().Instrs {
+		switch instr {
+		case a:
+			return true
+		case b:
+			return false
+		}
+	}
+	return false
+}
@@ -0,0 +1,211 @@
+package argtypes
+
+import (
+	"fmt"
+	"github.com/sema/cadencecheck/pkg/analysis"
+	"github.com/sema/cadencecheck/pkg/entities"
+	"github.com/sema/cadencecheck/pkg/reporter"
+	"go/types"
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/ssa"
+)
+
+const (
+	_kindArgumentCount = "ERROR-ARGUMENT-COUNT"
+	_kindArgumentType  = "ERROR-ARGUMENT-TYPE"
+	_kindResultType    = "ERROR-RESULT-TYPE"
+)
+
+// executePatterns maps functions executing activities and child workflows to a description of what is executed
+//
+// All functions share the signature (ctx, target interface{}, args ...interface{}) Future
+var executePatterns = map[entities.FunctionPattern]string{
+	{
+		Package: "go.uber.org/cadence/workflow",
+		Type:    "",
+		Method:  "ExecuteActivity",
+	}: "activity",
+	{
+		Package: "go.uber.org/cadence/workflow",
+		Type:    "",
+		Method:  "ExecuteLocalActivity",
+	}: "local activity",
+	{
+		Package: "go.uber.org/cadence/workflow",
+		Type:    "",
+		Method:  "ExecuteChildWorkflow",
+	}: "child workflow",
+}
+
+// Check compares the arguments passed when executing activities and child workflows, and the values their results
+// are decoded into, against the signature of the executed function
+type Check struct{}
+
+func New() *Check {
+	return &Check{}
+}
+
+func (c *Check) Check(f *ssa.Function, callGraph *callgraph.Graph, reporter *reporter.TerminalReporter) error {
+	root, ok := callGraph.Nodes[f]
+	if !ok {
+		return fmt.Errorf("could not find callgraph for function %s", reporter.FormatFunction(f))
+	}
+
+	var patterns []entities.FunctionPattern
+	for p := range executePatterns {
+		patterns = append(patterns, p)
+	}
+
+	for _, callSite := range analysis.FindCallSites(root, patterns) {
+		c.checkCallSite(callSite, executePatterns[callSite.Signature], callGraph, reporter)
+	}
+
+	return nil
+}
+
+func (c *Check) checkCallSite(
+	callSite analysis.CallSite,
+	description string,
+	callGraph *callgraph.Graph,
+	reporter *reporter.TerminalReporter,
+) {
+	common := callSite.Instruction.Common()
+
+	targets, err := analysis.ResolveFunctions(common.Args[1], callGraph, map[ssa.Value]bool{})
+	if err != nil {
+		// Targets referenced by name, or passed around in ways we can't follow, are not checked
+		reporter.Debug("unable to infer %s executed at %s: %s",
+			description, reporter.FormatCallSite(callSite.Instruction), err)
+		return
+	}
+
+	args, ok := analysis.VariadicArguments(common.Args[2])
+	if !ok {
+		reporter.Debug("unable to infer arguments passed to %s at %s",
+			description, reporter.FormatCallSite(callSite.Instruction))
+	}
+
+	for _, target := range targets {
+		if ok {
+			c.checkArguments(callSite, description, target, args, reporter)
+		}
+		c.checkResults(callSite, description, target, reporter)
+	}
+}
+
+func (c *Check) checkArguments(
+	callSite analysis.CallSite,
+	description string,
+	target *ssa.Function,
+	args []ssa.Value,
+	reporter *reporter.TerminalReporter,
+) {
+	params := analysis.PayloadParams(target.Signature)
+	variadic := target.Signature.Variadic()
+
+	if (!variadic && len(args) != len(params)) || (variadic && len(args) < len(params)-1) {
+		reporter.InstructionIssue(_kindArgumentCount, fmt.Sprintf(
+			"%s %s expects %d arguments, got %d",
+			description, target.RelString(nil), len(params), len(args)),
+			callSite.Instruction, callSite.StackTrace, callSite.VersionGuards())
+		return
+	}
+
+	for i, arg := range args {
+		var paramType types.Type
+		if variadic && i >= len(params)-1 {
+			paramType = params[len(params)-1].Type().(*types.Slice).Elem()
+		} else {
+			paramType = params[i].Type()
+		}
+
+		if analysis.IsNilConst(arg) {
+			if !isNillable(paramType) {
+				reporter.InstructionIssue(_kindArgumentType, fmt.Sprintf(
+					"argument %d to %s %s is nil, expected %s",
+					i+1, description, target.RelString(nil), types.TypeString(paramType, nil)),
+					callSite.Instruction, callSite.StackTrace, callSite.VersionGuards())
+			}
+			continue
+		}
+
+		argType, ok := analysis.ConcreteType(arg)
+		if !ok {
+			continue // passed as an interface value, the concrete type is decided at runtime
+		}
+
+		if !types.AssignableTo(argType, paramType) {
+			reporter.InstructionIssue(_kindArgumentType, fmt.Sprintf(
+				"argument %d to %s %s has type %s, expected %s",
+				i+1, description, target.RelString(nil),
+				types.TypeString(argType, nil), types.TypeString(paramType, nil)),
+				callSite.Instruction, callSite.StackTrace, callSite.VersionGuards())
+		}
+	}
+}
+
+// checkResults checks the values passed to Future.Get on the future returned when executing target
+func (c *Check) checkResults(
+	callSite analysis.CallSite,
+	description string,
+	target *ssa.Function,
+	reporter *reporter.TerminalReporter,
+) {
+	future, ok := callSite.Instruction.(*ssa.Call)
+	if !ok {
+		return // go and defer statements discard the future
+	}
+
+	result := analysis.PayloadResult(target.Signature)
+
+	for _, r := range *future.Referrers() {
+		get, ok := r.(*ssa.Call)
+		if !ok || !get.Call.IsInvoke() || get.Call.Value != future || get.Call.Method.Name() != "Get" {
+			continue
+		}
+
+		valuePtr := get.Call.Args[1]
+		if analysis.IsNilConst(valuePtr) {
+			continue // result is discarded
+		}
+
+		valueType, ok := analysis.ConcreteType(valuePtr)
+		if !ok {
+			continue
+		}
+
+		ptr, ok := valueType.Underlying().(*types.Pointer)
+		if !ok {
+			reporter.InstructionIssue(_kindResultType, fmt.Sprintf(
+				"result of %s %s is decoded into non-pointer type %s",
+				description, target.RelString(nil), types.TypeString(valueType, nil)),
+				get, callSite.StackTrace, analysis.PathVersionGuards(get, callSite.StackTrace))
+			continue
+		}
+
+		if result == nil {
+			reporter.InstructionIssue(_kindResultType, fmt.Sprintf(
+				"result of %s %s is decoded into %s, but %s only returns an error",
+				description, target.RelString(nil), types.TypeString(valueType, nil), description),
+				get, callSite.StackTrace, analysis.PathVersionGuards(get, callSite.StackTrace))
+			continue
+		}
+
+		if !types.AssignableTo(result, ptr.Elem()) {
+			reporter.InstructionIssue(_kindResultType, fmt.Sprintf(
+				"result of %s %s is decoded into %s, expected *%s",
+				description, target.RelString(nil),
+				types.TypeString(valueType, nil), types.TypeString(result, nil)),
+				get, callSite.StackTrace, analysis.PathVersionGuards(get, callSite.StackTrace))
+		}
+	}
+}
+
+func isNillable(typ types.Type) bool {
+	switch typ.Underlying().(type) {
+	case *types.Pointer, *types.Slice, *types.Map, *types.Chan, *types.Signature, *types.Interface:
+		return true
+	default:
+		return false
+	}
+}
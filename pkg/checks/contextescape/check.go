@@ -0,0 +1,344 @@
+package contextescape
+
+import (
+	"fmt"
+	"github.com/sema/cadencecheck/pkg/analysis"
+	"github.com/sema/cadencecheck/pkg/entities"
+	"github.com/sema/cadencecheck/pkg/reporter"
+	"go/token"
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/ssa"
+)
+
+const (
+	_kindContextEscapes = "ERROR-CONTEXT-ESCAPES"
+
+	_workflowPackage = "go.uber.org/cadence/workflow"
+)
+
+// Check follows the workflow.Context passed to a workflow, and reports it escaping the workflow invocation
+//
+// A workflow.Context escapes when it is stored in a package variable or in a struct which is not allocated by the
+// workflow invocation itself (e.g. the receiver of a workflow method), sent on a channel, or used by a native go
+// statement. Contexts derived from the workflow's context, e.g. by workflow.WithActivityOptions, are followed too.
+type Check struct{}
+
+func New() *Check {
+	return &Check{}
+}
+
+func (c *Check) Check(f *ssa.Function, callGraph *callgraph.Graph, reporter *reporter.TerminalReporter) error {
+	root, ok := callGraph.Nodes[f]
+	if !ok {
+		return fmt.Errorf("could not find callgraph for function %s", reporter.FormatFunction(f))
+	}
+
+	t := tracker{
+		root:        f,
+		callGraph:   callGraph,
+		reporter:    reporter,
+		stackTraces: map[*ssa.Function][]*callgraph.Edge{},
+		tracked:     map[ssa.Value]bool{},
+		containers:  map[ssa.Value]bool{},
+	}
+
+	analysis.VisitReachableFunctions(root, func(fn *ssa.Function, stackTrace []*callgraph.Edge) {
+		t.stackTraces[fn] = stackTrace
+	})
+
+	for _, param := range f.Params {
+		if analysis.IsWorkflowContext(param.Type()) {
+			t.track(param)
+		}
+	}
+
+	for len(t.queue) > 0 {
+		value := t.queue[0]
+		t.queue = t.queue[1:]
+		t.follow(value)
+	}
+
+	return nil
+}
+
+type tracker struct {
+	root        *ssa.Function
+	callGraph   *callgraph.Graph
+	reporter    *reporter.TerminalReporter
+	stackTraces map[*ssa.Function][]*callgraph.Edge
+
+	// tracked are values holding the workflow's context
+	tracked map[ssa.Value]bool
+	// containers are local variables the workflow's context is stored in
+	containers map[ssa.Value]bool
+	queue      []ssa.Value
+}
+
+func (t *tracker) track(value ssa.Value) {
+	if t.tracked[value] {
+		return
+	}
+	t.tracked[value] = true
+	t.queue = append(t.queue, value)
+}
+
+// contain records that variable holds the workflow's context, and tracks the values loaded from it
+func (t *tracker) contain(variable ssa.Value) {
+	if t.containers[variable] {
+		return
+	}
+	t.containers[variable] = true
+
+	for _, r := range *variable.Referrers() {
+		switch instr := r.(type) {
+		case *ssa.UnOp:
+			if instr.Op == token.MUL {
+				t.track(instr)
+			}
+		case *ssa.MakeClosure:
+			t.followClosure(instr)
+		}
+	}
+}
+
+// follow tracks the values derived from value, and reports the instructions through which it escapes
+func (t *tracker) follow(value ssa.Value) {
+	for _, r := range *value.Referrers() {
+		if _, ok := t.stackTraces[r.Parent()]; !ok {
+			continue // not reachable from the workflow
+		}
+
+		switch instr := r.(type) {
+		case *ssa.Store:
+			if instr.Val == value {
+				t.followStore(instr)
+			}
+
+		case *ssa.Send:
+			if instr.X == value {
+				t.report("workflow.Context is sent on a channel", instr)
+			}
+
+		case *ssa.MakeClosure:
+			t.followClosure(instr)
+
+		case ssa.CallInstruction:
+			t.followCall(instr, value)
+
+		case *ssa.Phi:
+			t.track(instr)
+		case *ssa.MakeInterface:
+			t.track(instr)
+		case *ssa.ChangeType:
+			t.track(instr)
+		}
+	}
+}
+
+func (t *tracker) followStore(store *ssa.Store) {
+	switch addr := store.Addr.(type) {
+	case *ssa.Global:
+		t.report(fmt.Sprintf("workflow.Context is stored in package variable %s", addr.RelString(nil)), store)
+
+	case *ssa.Alloc:
+		t.contain(addr)
+
+	case *ssa.FieldAddr, *ssa.IndexAddr:
+		if t.outlivesWorkflow(addr, map[ssa.Value]bool{}) {
+			t.report("workflow.Context is stored in a struct or collection which outlives the workflow", store)
+		}
+
+	case *ssa.FreeVar:
+		t.contain(addr)
+	}
+}
+
+// followClosure follows the workflow's context into closures capturing it
+func (t *tracker) followClosure(closure *ssa.MakeClosure) {
+	for i, binding := range closure.Bindings {
+		if !t.tracked[binding] && !t.containers[binding] {
+			continue
+		}
+
+		for _, r := range *closure.Referrers() {
+			if g, ok := r.(*ssa.Go); ok && g.Call.Value == closure {
+				t.report("workflow.Context is captured by a native go statement, use workflow.Go instead", g)
+			}
+		}
+
+		freeVar := closure.Fn.(*ssa.Function).FreeVars[i]
+		if t.tracked[binding] {
+			t.track(freeVar)
+		} else {
+			t.contain(freeVar)
+		}
+	}
+}
+
+func (t *tracker) followCall(call ssa.CallInstruction, value ssa.Value) {
+	common := call.Common()
+
+	if g, ok := call.(*ssa.Go); ok {
+		t.report("workflow.Context is passed to a native go statement, use workflow.Go instead", g)
+		return
+	}
+
+	callee := common.StaticCallee()
+	if callee == nil || callee.Pkg == nil {
+		return
+	}
+
+	// Contexts derived from the workflow's context, e.g. by workflow.WithActivityOptions
+	if entities.StripVendor(callee.Pkg.Pkg.Path()) == _workflowPackage {
+		if result := call.Value(); result != nil {
+			t.trackContextResults(result)
+		}
+		return
+	}
+
+	if analysis.IsLibraryFunction(callee) || len(callee.Params) != len(common.Args) {
+		return
+	}
+
+	for i, arg := range common.Args {
+		if arg == value {
+			t.track(callee.Params[i])
+		}
+	}
+}
+
+// trackContextResults tracks the workflow.Context results of a call, which may return a tuple
+func (t *tracker) trackContextResults(result *ssa.Call) {
+	if analysis.IsWorkflowContext(result.Type()) {
+		t.track(result)
+		return
+	}
+
+	for _, r := range *result.Referrers() {
+		if extract, ok := r.(*ssa.Extract); ok && analysis.IsWorkflowContext(extract.Type()) {
+			t.track(extract)
+		}
+	}
+}
+
+// outlivesWorkflow returns true if the struct, slice or map addr points into may outlive the workflow invocation,
+// i.e. is not allocated by functions called from the workflow
+func (t *tracker) outlivesWorkflow(addr ssa.Value, seen map[ssa.Value]bool) bool {
+	if seen[addr] {
+		return false
+	}
+	seen[addr] = true
+
+	switch v := addr.(type) {
+	case *ssa.FieldAddr:
+		return t.outlivesWorkflow(v.X, seen)
+	case *ssa.IndexAddr:
+		return t.outlivesWorkflow(v.X, seen)
+	case *ssa.Field:
+		return t.outlivesWorkflow(v.X, seen)
+	case *ssa.Alloc, *ssa.MakeMap, *ssa.MakeSlice:
+		return false
+	case *ssa.Global:
+		return true
+
+	case *ssa.UnOp:
+		if v.Op != token.MUL {
+			return false
+		}
+		if _, ok := v.X.(*ssa.Global); ok {
+			return true
+		}
+		// pointer loaded from a variable, check the values stored to it
+		for _, r := range *v.X.Referrers() {
+			if store, ok := r.(*ssa.Store); ok && store.Addr == v.X && t.outlivesWorkflow(store.Val, seen) {
+				return true
+			}
+		}
+		return false
+
+	case *ssa.FreeVar:
+		if v.Parent().Parent() == nil {
+			return true // receiver of a bound method, e.g. a workflow registered as w.Workflow
+		}
+		for _, closure := range makeClosures(v) {
+			if t.outlivesWorkflow(closure.Bindings[freeVarIndex(v)], seen) {
+				return true
+			}
+		}
+		return false
+
+	case *ssa.Parameter:
+		if v.Parent() == t.root {
+			return true // e.g. the receiver of a workflow method
+		}
+		return t.callerArgumentsOutlive(v, seen)
+
+	default:
+		return false // e.g. returned by a constructor, assume it is allocated by the workflow
+	}
+}
+
+// callerArgumentsOutlive returns true if any argument passed for param by callers within the workflow outlives it
+func (t *tracker) callerArgumentsOutlive(param *ssa.Parameter, seen map[ssa.Value]bool) bool {
+	fn := param.Parent()
+
+	idx := -1
+	for i, p := range fn.Params {
+		if p == param {
+			idx = i
+		}
+	}
+
+	node := t.callGraph.Nodes[fn]
+	if node == nil || idx < 0 {
+		return false
+	}
+
+	for _, edge := range node.In {
+		if _, ok := t.stackTraces[edge.Caller.Func]; !ok {
+			continue
+		}
+
+		args := edge.Site.Common().Args
+		if edge.Site.Common().IsInvoke() || len(args) != len(fn.Params) {
+			continue
+		}
+		if t.outlivesWorkflow(args[idx], seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// makeClosures returns the MakeClosure instructions binding freeVar
+func makeClosures(freeVar *ssa.FreeVar) []*ssa.MakeClosure {
+	fn := freeVar.Parent()
+	if fn.Parent() == nil {
+		return nil
+	}
+
+	var result []*ssa.MakeClosure
+	for _, block := range fn.Parent().Blocks {
+		for _, instr := range block.Instrs {
+			if closure, ok := instr.(*ssa.MakeClosure); ok && closure.Fn == fn {
+				result = append(result, closure)
+			}
+		}
+	}
+	return result
+}
+
+func freeVarIndex(freeVar *ssa.FreeVar) int {
+	for i, fv := range freeVar.Parent().FreeVars {
+		if fv == freeVar {
+			return i
+		}
+	}
+	return -1
+}
+
+func (t *tracker) report(message string, instr ssa.Instruction) {
+	stackTrace := t.stackTraces[instr.Parent()]
+	t.reporter.InstructionIssue(_kindContextEscapes, message,
+		instr, stackTrace, analysis.PathVersionGuards(instr, stackTrace))
+}
@@ -0,0 +1,107 @@
+package continueasnew
+
+import (
+	"fmt"
+	"github.com/sema/cadencecheck/pkg/analysis"
+	"github.com/sema/cadencecheck/pkg/commands"
+	"github.com/sema/cadencecheck/pkg/reporter"
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/ssa"
+	"strings"
+)
+
+const (
+	_kindUnboundedHistory = "WARNING-UNBOUNDED-HISTORY"
+
+	_workflowPackage = "go.uber.org/cadence/workflow"
+)
+
+// Check reports loops whose body produces decisions, e.g. scheduling activities or timers, without being bounded and
+// without an exit returning workflow.NewContinueAsNewError. The history of such a workflow grows until it hits the
+// history size limit.
+//
+// Loops counting towards a bound, or ranging over a slice, map or string, are assumed to be bounded. GetVersion is
+// not considered a decision, as its marker is only recorded once per change ID.
+type Check struct{}
+
+func New() *Check {
+	return &Check{}
+}
+
+func (c *Check) Check(f *ssa.Function, callGraph *callgraph.Graph, reporter *reporter.TerminalReporter) error {
+	root, ok := callGraph.Nodes[f]
+	if !ok {
+		return fmt.Errorf("could not find callgraph for function %s", reporter.FormatFunction(f))
+	}
+
+	decisions := analysis.NewCallSummary(callGraph, func(call *ssa.Call) bool {
+		command, ok := commands.FindDecision(call, callGraph)
+		return ok && command.Kind != "GetVersion"
+	})
+	continues := analysis.NewCallSummary(callGraph, isContinueAsNewError)
+
+	analysis.VisitReachableFunctions(root, func(fn *ssa.Function, stackTrace []*callgraph.Edge) {
+		for _, l := range analysis.FindLoops(fn) {
+			if l.RangesOverMapOrString() || l.Counted() || continuesAsNewAfter(l, continues) {
+				continue
+			}
+
+			var calls []string
+			for _, block := range l.Blocks() {
+				for _, instr := range block.Instrs {
+					if call, ok := instr.(*ssa.Call); ok && decisions.Call(call) {
+						calls = append(calls, fmt.Sprintf(
+							"%s at %s", describe(call, callGraph), reporter.FormatCallSite(call)))
+					}
+				}
+			}
+			if len(calls) == 0 {
+				continue
+			}
+
+			reporter.InstructionIssue(_kindUnboundedHistory, fmt.Sprintf(
+				"loop produces decisions without an exit returning workflow.NewContinueAsNewError, its history "+
+					"grows without bound: %s", strings.Join(calls, ", ")),
+				l.Position(), stackTrace, analysis.PathVersionGuards(l.Position(), stackTrace))
+		}
+	})
+
+	return nil
+}
+
+func isContinueAsNewError(call *ssa.Call) bool {
+	signature, err := analysis.CallSignature(call.Common())
+	return err == nil && signature.Package == _workflowPackage && signature.Type == "" &&
+		signature.Method == "NewContinueAsNewError"
+}
+
+// continuesAsNewAfter returns true if a ContinueAsNew error is created on some path leaving the loop
+func continuesAsNewAfter(l *analysis.Loop, continues *analysis.CallSummary) bool {
+	seen := map[*ssa.BasicBlock]bool{}
+	queue := l.Exits()
+	for len(queue) > 0 {
+		b := queue[0]
+		queue = queue[1:]
+		if seen[b] {
+			continue
+		}
+		seen[b] = true
+
+		if continues.Blocks([]*ssa.BasicBlock{b}) {
+			return true
+		}
+		queue = append(queue, b.Succs...)
+	}
+	return false
+}
+
+// describe names the decision produced by call, or the function called by it producing decisions
+func describe(call *ssa.Call, callGraph *callgraph.Graph) string {
+	if command, ok := commands.FindDecision(call, callGraph); ok {
+		return command.String()
+	}
+	if callee := call.Call.StaticCallee(); callee != nil {
+		return fmt.Sprintf("call to %s", callee.Name())
+	}
+	return "call"
+}
@@ -0,0 +1,242 @@
+package deadlockrisk
+
+import (
+	"fmt"
+	"github.com/sema/cadencecheck/pkg/analysis"
+	"github.com/sema/cadencecheck/pkg/entities"
+	"github.com/sema/cadencecheck/pkg/reporter"
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/ssa"
+)
+
+const (
+	_kindBusyLoop  = "WARNING-BUSY-LOOP"
+	_kindHeavyCall = "WARNING-HEAVY-CALL"
+)
+
+// blockingFunctions yield control back to the Cadence client, resetting the deadlock detector
+var blockingFunctions = []entities.FunctionPattern{
+	{
+		Package: "go.uber.org/cadence/workflow",
+		Type:    "",
+		Method:  "Sleep",
+	},
+	{
+		Package: "go.uber.org/cadence/workflow",
+		Type:    "",
+		Method:  "Await",
+	},
+	{
+		Package: "go.uber.org/cadence/workflow",
+		Type:    "",
+		Method:  "AwaitWithTimeout",
+	},
+	{
+		Package: "go.uber.org/cadence/internal",
+		Type:    "Future",
+		Method:  "Get",
+	},
+	{
+		Package: "go.uber.org/cadence/internal",
+		Type:    "ChildWorkflowFuture",
+		Method:  "Get",
+	},
+	{
+		Package: "go.uber.org/cadence/internal",
+		Type:    "decodeFutureImpl",
+		Method:  "Get",
+	},
+	{
+		Package: "go.uber.org/cadence/internal",
+		Type:    "futureImpl",
+		Method:  "Get",
+	},
+	{
+		Package: "go.uber.org/cadence/internal",
+		Type:    "Channel",
+		Method:  "Receive",
+	},
+	{
+		Package: "go.uber.org/cadence/internal",
+		Type:    "Selector",
+		Method:  "Select",
+	},
+	{
+		Package: "go.uber.org/cadence/internal",
+		Type:    "WaitGroup",
+		Method:  "Wait",
+	},
+}
+
+// DefaultHeavyFunctions are functions which may run for long enough on large inputs to trigger the deadlock detector
+var DefaultHeavyFunctions = []entities.FunctionPattern{
+	{
+		Package: "compress/gzip",
+		Type:    "Writer",
+		Method:  "Write",
+	},
+	{
+		Package: "compress/gzip",
+		Type:    "Reader",
+		Method:  "Read",
+	},
+	{
+		Package: "compress/zlib",
+		Type:    "Writer",
+		Method:  "Write",
+	},
+	{
+		Package: "compress/flate",
+		Type:    "Writer",
+		Method:  "Write",
+	},
+	{
+		Package: "crypto/md5",
+		Type:    "",
+		Method:  "Sum",
+	},
+	{
+		Package: "crypto/sha1",
+		Type:    "",
+		Method:  "Sum",
+	},
+	{
+		Package: "crypto/sha256",
+		Type:    "",
+		Method:  "Sum256",
+	},
+	{
+		Package: "crypto/sha512",
+		Type:    "",
+		Method:  "Sum512",
+	},
+	{
+		Package: "golang.org/x/crypto/bcrypt",
+		Type:    "",
+		Method:  "GenerateFromPassword",
+	},
+	{
+		Package: "golang.org/x/crypto/bcrypt",
+		Type:    "",
+		Method:  "CompareHashAndPassword",
+	},
+	{
+		Package: "encoding/json",
+		Type:    "",
+		Method:  "Marshal",
+	},
+	{
+		Package: "encoding/json",
+		Type:    "",
+		Method:  "MarshalIndent",
+	},
+	{
+		Package: "encoding/json",
+		Type:    "",
+		Method:  "Unmarshal",
+	},
+	{
+		Package: "encoding/json",
+		Type:    "Encoder",
+		Method:  "Encode",
+	},
+	{
+		Package: "encoding/json",
+		Type:    "Decoder",
+		Method:  "Decode",
+	},
+	{
+		Package: "sort",
+		Type:    "",
+		Method:  "Sort",
+	},
+	{
+		Package: "sort",
+		Type:    "",
+		Method:  "Stable",
+	},
+	{
+		Package: "sort",
+		Type:    "",
+		Method:  "Slice",
+	},
+	{
+		Package: "sort",
+		Type:    "",
+		Method:  "SliceStable",
+	},
+}
+
+// Check reports code which may run for longer than the deadlock detector allows a workflow to run without yielding
+//
+// This is a heuristic: loops are reported if their body never blocks on a workflow call (e.g. Future.Get or
+// workflow.Sleep) and they are not bounded by a constant, and calls to heavy functions are reported wherever they
+// are made from a workflow. Loops over the elements of a slice, map or string are bounded by data the workflow
+// already holds in memory, and are not reported.
+type Check struct {
+	blocking map[entities.FunctionPattern]bool
+	heavy    map[entities.FunctionPattern]bool
+}
+
+// New creates a check reporting calls to the given heavy functions, e.g. DefaultHeavyFunctions
+func New(heavyFunctions []entities.FunctionPattern) *Check {
+	blocking := map[entities.FunctionPattern]bool{}
+	for _, b := range blockingFunctions {
+		blocking[b] = true
+	}
+
+	heavy := map[entities.FunctionPattern]bool{}
+	for _, h := range heavyFunctions {
+		heavy[h] = true
+	}
+
+	return &Check{
+		blocking: blocking,
+		heavy:    heavy,
+	}
+}
+
+func (c *Check) Check(f *ssa.Function, callGraph *callgraph.Graph, reporter *reporter.TerminalReporter) error {
+	root, ok := callGraph.Nodes[f]
+	if !ok {
+		return fmt.Errorf("could not find callgraph for function %s", reporter.FormatFunction(f))
+	}
+
+	blocks := analysis.NewCallSummary(callGraph, func(call *ssa.Call) bool {
+		signature, err := analysis.CallSignature(call.Common())
+		return err == nil && c.blocking[signature]
+	})
+
+	analysis.VisitReachableFunctions(root, func(fn *ssa.Function, stackTrace []*callgraph.Edge) {
+		for _, l := range analysis.FindLoops(fn) {
+			if l.ConstantBound() || l.IteratesCollection() || blocks.Blocks(l.Blocks()) {
+				continue
+			}
+
+			reporter.InstructionIssue(_kindBusyLoop,
+				"loop without a constant bound never blocks on a workflow call, it may run long enough to "+
+					"trigger the deadlock detector",
+				l.Position(), stackTrace, analysis.PathVersionGuards(l.Position(), stackTrace))
+		}
+
+		for _, block := range fn.Blocks {
+			for _, instr := range block.Instrs {
+				call, ok := instr.(ssa.CallInstruction)
+				if !ok {
+					continue
+				}
+
+				signature, err := analysis.CallSignature(call.Common())
+				if err != nil || !c.heavy[signature] {
+					continue
+				}
+
+				reporter.InstructionIssue(_kindHeavyCall, fmt.Sprintf(
+					"call to %s may run long enough to trigger the deadlock detector, consider moving it to an activity",
+					signature.String()), call, stackTrace, analysis.PathVersionGuards(call, stackTrace))
+			}
+		}
+	})
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package futures
+
+import (
+	"fmt"
+	"github.com/sema/cadencecheck/pkg/analysis"
+	"github.com/sema/cadencecheck/pkg/reporter"
+	"go/types"
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/ssa"
+)
+
+const (
+	_kindFutureNotAwaited = "ERROR-FUTURE-NOT-AWAITED"
+	_kindErrorIgnored     = "ERROR-FUTURE-ERROR-IGNORED"
+
+	_cadenceInternalPackage = "go.uber.org/cadence/internal"
+)
+
+// Check reports futures which are never awaited, and calls to Future.Get whose returned error is discarded
+//
+// Futures passed on to other functions (e.g. Selector.AddFuture), stored or returned are assumed to be awaited
+// elsewhere.
+type Check struct{}
+
+func New() *Check {
+	return &Check{}
+}
+
+func (c *Check) Check(f *ssa.Function, callGraph *callgraph.Graph, reporter *reporter.TerminalReporter) error {
+	root, ok := callGraph.Nodes[f]
+	if !ok {
+		return fmt.Errorf("could not find callgraph for function %s", reporter.FormatFunction(f))
+	}
+
+	analysis.VisitReachableFunctions(root, func(fn *ssa.Function, stackTrace []*callgraph.Edge) {
+		for _, block := range fn.Blocks {
+			for _, instr := range block.Instrs {
+				call, ok := instr.(ssa.CallInstruction)
+				if !ok {
+					continue
+				}
+
+				if isFutureGet(call.Common()) {
+					c.checkGet(call, stackTrace, reporter)
+				}
+				if returnsFuture(call.Common()) {
+					c.checkAwaited(call, stackTrace, reporter)
+				}
+			}
+		}
+	})
+
+	return nil
+}
+
+// checkAwaited reports calls returning a future which is neither awaited nor passed on
+func (c *Check) checkAwaited(call ssa.CallInstruction, stackTrace []*callgraph.Edge, reporter *reporter.TerminalReporter) {
+	// go and defer statements discard the future
+	if future := call.Value(); future != nil && isAwaited(future) {
+		return
+	}
+
+	reporter.InstructionIssue(_kindFutureNotAwaited, fmt.Sprintf(
+		"future returned by %s is never awaited, its failure is lost", describeCall(call.Common())),
+		call, stackTrace, analysis.PathVersionGuards(call, stackTrace))
+}
+
+// isAwaited returns true if the future is awaited or passed on. Awaiting the execution of a child workflow
+// (ChildWorkflowFuture.GetChildWorkflowExecution) consumes the child workflow future as well.
+func isAwaited(future ssa.Value) bool {
+	for _, r := range *future.Referrers() {
+		refCall, ok := r.(ssa.CallInstruction)
+		if !ok {
+			return true // stored, returned or merged with other values
+		}
+
+		common := refCall.Common()
+		if !common.IsInvoke() || common.Value != future || common.Method.Name() == "Get" {
+			return true // awaited, or passed as an argument
+		}
+		if common.Method.Name() == "GetChildWorkflowExecution" {
+			if execution := refCall.Value(); execution != nil && isAwaited(execution) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkGet reports calls to Future.Get whose returned error is not used
+func (c *Check) checkGet(call ssa.CallInstruction, stackTrace []*callgraph.Edge, reporter *reporter.TerminalReporter) {
+	if value := call.Value(); value != nil && len(*value.Referrers()) > 0 {
+		return
+	}
+
+	reporter.InstructionIssue(_kindErrorIgnored, fmt.Sprintf(
+		"error returned by %s is ignored, its failure is lost", describeCall(call.Common())),
+		call, stackTrace, analysis.PathVersionGuards(call, stackTrace))
+}
+
+func isFutureGet(call *ssa.CallCommon) bool {
+	return call.IsInvoke() && call.Method.Name() == "Get" && isFuture(call.Value.Type())
+}
+
+func returnsFuture(call *ssa.CallCommon) bool {
+	results := call.Signature().Results()
+	return results.Len() == 1 && isFuture(results.At(0).Type())
+}
+
+// isFuture returns true for workflow.Future and workflow.ChildWorkflowFuture, aliases of the internal types
+func isFuture(typ types.Type) bool {
+	return analysis.IsNamedType(typ, _cadenceInternalPackage, "Future") ||
+		analysis.IsNamedType(typ, _cadenceInternalPackage, "ChildWorkflowFuture")
+}
+
+func describeCall(call *ssa.CallCommon) string {
+	signature, err := analysis.CallSignature(call)
+	if err != nil || signature.Method == "" {
+		return "function call"
+	}
+	return signature.String()
+}
@@ -0,0 +1,111 @@
+package nonworkflowapis
+
+import (
+	"fmt"
+	"github.com/sema/cadencecheck/pkg/analysis"
+	"github.com/sema/cadencecheck/pkg/entities"
+	"github.com/sema/cadencecheck/pkg/reporter"
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/ssa"
+)
+
+const (
+	_kindClientCall  = "ERROR-CLIENT-CALL-IN-WORKFLOW"
+	_kindActivityAPI = "ERROR-ACTIVITY-API-IN-WORKFLOW"
+
+	_clientPackage   = "go.uber.org/cadence/client"
+	_activityPackage = "go.uber.org/cadence/activity"
+	_internalPackage = "go.uber.org/cadence/internal"
+)
+
+// clientTypes are the types of the client package, which are aliases of internal types
+var clientTypes = map[string]bool{
+	"Client":       true,
+	"DomainClient": true,
+	"WorkflowRun":  true,
+}
+
+// clientAlternatives maps client methods to the workflow API achieving the same from within a workflow
+var clientAlternatives = map[string]string{
+	"StartWorkflow":           "use workflow.ExecuteChildWorkflow instead",
+	"ExecuteWorkflow":         "use workflow.ExecuteChildWorkflow instead",
+	"SignalWithStartWorkflow": "use workflow.ExecuteChildWorkflow instead",
+	"SignalWorkflow":          "use workflow.SignalExternalWorkflow instead",
+	"CancelWorkflow":          "use workflow.RequestCancelExternalWorkflow instead",
+}
+
+// activityAlternatives maps activity functions to the workflow API achieving the same from within a workflow
+var activityAlternatives = map[string]string{
+	"GetLogger":       "use workflow.GetLogger instead",
+	"GetMetricsScope": "use workflow.GetMetricsScope instead",
+	"GetInfo":         "use workflow.GetInfo instead",
+}
+
+// activityRegistration are the functions of the activity package which may be called from anywhere
+var activityRegistration = map[string]bool{
+	"Register":            true,
+	"RegisterWithOptions": true,
+}
+
+// Check reports calls from workflows to the Cadence client, which are non-deterministic RPCs, and to the activity
+// package, whose functions panic when not called from an activity
+type Check struct{}
+
+func New() *Check {
+	return &Check{}
+}
+
+func (c *Check) Check(f *ssa.Function, callGraph *callgraph.Graph, reporter *reporter.TerminalReporter) error {
+	root, ok := callGraph.Nodes[f]
+	if !ok {
+		return fmt.Errorf("could not find callgraph for function %s", reporter.FormatFunction(f))
+	}
+
+	analysis.VisitReachableFunctions(root, func(fn *ssa.Function, stackTrace []*callgraph.Edge) {
+		for _, block := range fn.Blocks {
+			for _, instr := range block.Instrs {
+				call, ok := instr.(ssa.CallInstruction)
+				if !ok {
+					continue
+				}
+
+				signature, err := analysis.CallSignature(call.Common())
+				if err != nil {
+					continue
+				}
+
+				switch {
+				case isClientCall(signature):
+					reporter.InstructionIssue(_kindClientCall, fmt.Sprintf(
+						"workflow calls %s, a non-deterministic request to the Cadence server; %s",
+						signature.String(), alternative(clientAlternatives, signature.Method)),
+						call, stackTrace, analysis.PathVersionGuards(call, stackTrace))
+
+				case isActivityCall(signature):
+					reporter.InstructionIssue(_kindActivityAPI, fmt.Sprintf(
+						"workflow calls %s, which panics when not called from an activity; %s",
+						signature.String(), alternative(activityAlternatives, signature.Method)),
+						call, stackTrace, analysis.PathVersionGuards(call, stackTrace))
+				}
+			}
+		}
+	})
+
+	return nil
+}
+
+func isClientCall(signature entities.FunctionPattern) bool {
+	return signature.Package == _clientPackage ||
+		(signature.Package == _internalPackage && clientTypes[signature.Type])
+}
+
+func isActivityCall(signature entities.FunctionPattern) bool {
+	return signature.Package == _activityPackage && !activityRegistration[signature.Method]
+}
+
+func alternative(alternatives map[string]string, method string) string {
+	if a := alternatives[method]; a != "" {
+		return a
+	}
+	return "move the logic into an activity"
+}
@@ -0,0 +1,199 @@
+package optionvalues
+
+import (
+	"fmt"
+	"github.com/sema/cadencecheck/pkg/analysis"
+	"github.com/sema/cadencecheck/pkg/entities"
+	"github.com/sema/cadencecheck/pkg/reporter"
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/ssa"
+	"time"
+)
+
+const (
+	_kindInvalidOptions = "ERROR-INVALID-OPTIONS"
+)
+
+type validator func(options *analysis.StructLiteral) []string
+
+type optionsKind struct {
+	name     string
+	validate validator
+}
+
+// optionFunctions maps the functions applying options to a workflow.Context to the validation of their options
+var optionFunctions = map[entities.FunctionPattern]optionsKind{
+	{
+		Package: "go.uber.org/cadence/workflow",
+		Type:    "",
+		Method:  "WithActivityOptions",
+	}: {"ActivityOptions", validateActivityOptions},
+	{
+		Package: "go.uber.org/cadence/workflow",
+		Type:    "",
+		Method:  "WithLocalActivityOptions",
+	}: {"LocalActivityOptions", validateLocalActivityOptions},
+	{
+		Package: "go.uber.org/cadence/workflow",
+		Type:    "",
+		Method:  "WithChildOptions",
+	}: {"ChildWorkflowOptions", validateChildWorkflowOptions},
+}
+
+// Check validates activity and child workflow options created from composite literals with constant fields
+//
+// Fields assigned non-constant values are not validated.
+type Check struct{}
+
+func New() *Check {
+	return &Check{}
+}
+
+func (c *Check) Check(f *ssa.Function, callGraph *callgraph.Graph, reporter *reporter.TerminalReporter) error {
+	root, ok := callGraph.Nodes[f]
+	if !ok {
+		return fmt.Errorf("could not find callgraph for function %s", reporter.FormatFunction(f))
+	}
+
+	var patterns []entities.FunctionPattern
+	for p := range optionFunctions {
+		patterns = append(patterns, p)
+	}
+
+	for _, callSite := range analysis.FindCallSites(root, patterns) {
+		kind := optionFunctions[callSite.Signature]
+
+		options, ok := analysis.FoldStruct(callSite.Instruction.Common().Args[1])
+		if !ok {
+			reporter.Debug("unable to infer %s passed at %s", kind.name, reporter.FormatCallSite(callSite.Instruction))
+			continue
+		}
+
+		// Report at the composite literal if there is one, otherwise at the call applying the options
+		var position ssa.Instruction = callSite.Instruction
+		if options.Alloc != nil {
+			position = options.Alloc
+		}
+
+		for _, problem := range kind.validate(options) {
+			reporter.InstructionIssue(_kindInvalidOptions, fmt.Sprintf("invalid %s: %s", kind.name, problem),
+				position, callSite.StackTrace, analysis.PathVersionGuards(position, callSite.StackTrace))
+		}
+	}
+
+	return nil
+}
+
+func validateActivityOptions(options *analysis.StructLiteral) []string {
+	var problems []string
+
+	problems = append(problems, requirePositive(options, "ScheduleToStartTimeout")...)
+	problems = append(problems, requirePositive(options, "StartToCloseTimeout")...)
+	problems = append(problems, requireNotNegative(options, "ScheduleToCloseTimeout")...)
+	problems = append(problems, requireNotNegative(options, "HeartbeatTimeout")...)
+	problems = append(problems, requireNotLonger(options, "StartToCloseTimeout", "ScheduleToCloseTimeout")...)
+	problems = append(problems, requireNotLonger(options, "ScheduleToStartTimeout", "ScheduleToCloseTimeout")...)
+	problems = append(problems, requireNotEmpty(options, "TaskList")...)
+	problems = append(problems, validateRetryPolicy(options)...)
+
+	return problems
+}
+
+func validateLocalActivityOptions(options *analysis.StructLiteral) []string {
+	var problems []string
+
+	problems = append(problems, requirePositive(options, "ScheduleToCloseTimeout")...)
+	problems = append(problems, validateRetryPolicy(options)...)
+
+	return problems
+}
+
+func validateChildWorkflowOptions(options *analysis.StructLiteral) []string {
+	var problems []string
+
+	problems = append(problems, requirePositive(options, "ExecutionStartToCloseTimeout")...)
+	problems = append(problems, requireNotNegative(options, "TaskStartToCloseTimeout")...)
+	problems = append(problems, requireNotEmpty(options, "TaskList")...)
+	problems = append(problems, requireNotEmpty(options, "Domain")...)
+	problems = append(problems, validateRetryPolicy(options)...)
+
+	return problems
+}
+
+func validateRetryPolicy(options *analysis.StructLiteral) []string {
+	policy, ok := options.StructField("RetryPolicy")
+	if !ok || policy == nil {
+		return nil
+	}
+
+	var problems []string
+	for _, problem := range requirePositive(policy, "InitialInterval") {
+		problems = append(problems, "RetryPolicy."+problem)
+	}
+	for _, problem := range requireNotLonger(policy, "InitialInterval", "MaximumInterval") {
+		problems = append(problems, "RetryPolicy."+problem)
+	}
+
+	if coefficient, ok := policy.FloatField("BackoffCoefficient"); ok && policy.IsSet("BackoffCoefficient") &&
+		coefficient < 1 {
+		problems = append(problems, fmt.Sprintf("RetryPolicy.BackoffCoefficient is %g, must be at least 1", coefficient))
+	}
+
+	attempts, attemptsOk := policy.IntField("MaximumAttempts")
+	expiration, expirationOk := policy.IntField("ExpirationInterval")
+	if attemptsOk && attempts < 0 {
+		problems = append(problems, fmt.Sprintf("RetryPolicy.MaximumAttempts is %d, must not be negative", attempts))
+	}
+	if attemptsOk && expirationOk && expiration == 0 {
+		if attempts > 0 {
+			problems = append(problems, "RetryPolicy sets MaximumAttempts without ExpirationInterval")
+		} else {
+			problems = append(problems, "RetryPolicy sets neither MaximumAttempts nor ExpirationInterval")
+		}
+	}
+
+	return problems
+}
+
+func requirePositive(options *analysis.StructLiteral, field string) []string {
+	value, ok := options.IntField(field)
+	if !ok || value > 0 {
+		return nil
+	}
+
+	if value == 0 {
+		return []string{fmt.Sprintf("%s is not set", field)}
+	}
+	return []string{fmt.Sprintf("%s is %s, must be positive", field, time.Duration(value))}
+}
+
+func requireNotNegative(options *analysis.StructLiteral, field string) []string {
+	value, ok := options.IntField(field)
+	if !ok || value >= 0 {
+		return nil
+	}
+
+	return []string{fmt.Sprintf("%s is %s, must not be negative", field, time.Duration(value))}
+}
+
+// requireNotLonger requires the shorter field to not exceed the longer field, if both are set
+func requireNotLonger(options *analysis.StructLiteral, shorterField string, longerField string) []string {
+	shorter, shorterOk := options.IntField(shorterField)
+	longer, longerOk := options.IntField(longerField)
+	if !shorterOk || !longerOk || shorter == 0 || longer == 0 || shorter <= longer {
+		return nil
+	}
+
+	return []string{fmt.Sprintf("%s (%s) is greater than %s (%s)",
+		shorterField, time.Duration(shorter), longerField, time.Duration(longer))}
+}
+
+// requireNotEmpty reports string fields explicitly set to the empty string, unset fields fall back to defaults
+func requireNotEmpty(options *analysis.StructLiteral, field string) []string {
+	value, ok := options.StringField(field)
+	if !ok || !options.IsSet(field) || value != "" {
+		return nil
+	}
+
+	return []string{fmt.Sprintf("%s is set to an empty string", field)}
+}
@@ -0,0 +1,108 @@
+package outsideworkflow
+
+import (
+	"fmt"
+	"github.com/sema/cadencecheck/pkg/analysis"
+	"github.com/sema/cadencecheck/pkg/entities"
+	"github.com/sema/cadencecheck/pkg/reporter"
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/ssa"
+)
+
+const (
+	_kindWorkflowAPIOutsideWorkflow = "ERROR-WORKFLOW-API-OUTSIDE-WORKFLOW"
+
+	_workflowPackage = "go.uber.org/cadence/workflow"
+)
+
+// allowed are functions of the workflow package which are meant to be called outside of workflows
+var allowed = map[string]bool{
+	"Register":            true,
+	"RegisterWithOptions": true,
+}
+
+// Check reports calls to the workflow package from application code which is not reachable from any workflow or
+// query handler. Such code runs without a workflow.Context, and the called APIs panic at runtime.
+//
+// Functions reachable from both workflows and other code are not reported.
+type Check struct{}
+
+func New() *Check {
+	return &Check{}
+}
+
+func (c *Check) CheckProgram(
+	entrypoints analysis.Entrypoints,
+	callGraph *callgraph.Graph,
+	reporter *reporter.TerminalReporter,
+) error {
+	workflowCode := map[*ssa.Function]bool{}
+	for _, f := range append(append([]*ssa.Function{}, entrypoints.Workflows...), entrypoints.QueryHandlers...) {
+		node, ok := callGraph.Nodes[f]
+		if !ok {
+			return fmt.Errorf("could not find callgraph for function %s", reporter.FormatFunction(f))
+		}
+
+		workflowCode[f] = true
+		analysis.GraphVisitEdges(node, func(edge *callgraph.Edge, previous []*callgraph.Edge) (follow bool) {
+			workflowCode[edge.Callee.Func] = true
+			return true
+		})
+	}
+
+	roots := map[*ssa.Function]bool{}
+	for _, f := range append(append([]*ssa.Function{}, entrypoints.Roots...), entrypoints.Activities...) {
+		roots[f] = true
+	}
+
+	for _, f := range analysis.SortedFunctions(callGraph) {
+		if workflowCode[f] || analysis.IsLibraryFunction(f) {
+			continue
+		}
+
+		for _, callSite := range workflowCalls(f) {
+			stackTrace := analysis.FindPathFromRoot(callGraph.Nodes[f], func(f *ssa.Function) bool {
+				return roots[f]
+			})
+			if stackTrace == nil {
+				continue // only reachable from workflows through edges missing from the call graph
+			}
+
+			root := f
+			if len(stackTrace) > 0 {
+				root = stackTrace[0].Caller.Func
+			}
+
+			signature, _ := analysis.CallSignature(callSite.Common())
+			reporter.InstructionIssue(_kindWorkflowAPIOutsideWorkflow, fmt.Sprintf(
+				"%s is called outside of workflow code (reached from %s) and will panic without a workflow context",
+				signature.String(), root.RelString(nil)),
+				callSite, stackTrace, analysis.PathVersionGuards(callSite, stackTrace))
+		}
+	}
+
+	return nil
+}
+
+// workflowCalls returns the calls made by f to functions of the workflow package
+func workflowCalls(f *ssa.Function) []ssa.CallInstruction {
+	var result []ssa.CallInstruction
+	for _, block := range f.Blocks {
+		for _, instr := range block.Instrs {
+			call, ok := instr.(ssa.CallInstruction)
+			if !ok {
+				continue
+			}
+
+			callee := call.Common().StaticCallee()
+			if callee == nil || callee.Pkg == nil || callee.Synthetic != "" || callee.Signature.Recv() != nil {
+				continue
+			}
+
+			if entities.StripVendor(callee.Pkg.Pkg.Path()) == _workflowPackage && !allowed[callee.Name()] {
+				result = append(result, call)
+			}
+		}
+	}
+	return result
+}
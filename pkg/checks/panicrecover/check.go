@@ -0,0 +1,64 @@
+package panicrecover
+
+import (
+	"fmt"
+	"github.com/sema/cadencecheck/pkg/analysis"
+	"github.com/sema/cadencecheck/pkg/reporter"
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/ssa"
+)
+
+const (
+	_kindPanic   = "PANIC"
+	_kindRecover = "RECOVER"
+)
+
+// Check reports panics and calls to recover in code reachable from workflows
+//
+// Cadence unwinds blocked workflow coroutines by panicking, which a recover in workflow code may swallow. Panics
+// raised by workflow code fail the decision task, which is retried until the workflow is fixed.
+//
+// Like the other checks, all packages of the application are checked, including helper packages besides the one
+// of the workflow. Libraries (see analysis.IsLibraryFunction), which commonly recover from their own panics, are not.
+type Check struct {
+	severity reporter.Severity
+}
+
+func New(severity reporter.Severity) *Check {
+	return &Check{
+		severity: severity,
+	}
+}
+
+func (c *Check) Check(f *ssa.Function, callGraph *callgraph.Graph, reporter *reporter.TerminalReporter) error {
+	root, ok := callGraph.Nodes[f]
+	if !ok {
+		return fmt.Errorf("could not find callgraph for function %s", reporter.FormatFunction(f))
+	}
+
+	analysis.VisitReachableFunctions(root, func(fn *ssa.Function, stackTrace []*callgraph.Edge) {
+		c.checkFunction(fn, stackTrace, reporter)
+	})
+
+	return nil
+}
+
+func (c *Check) checkFunction(f *ssa.Function, stackTrace []*callgraph.Edge, reporter *reporter.TerminalReporter) {
+	for _, block := range f.Blocks {
+		for _, instr := range block.Instrs {
+			switch i := instr.(type) {
+			case *ssa.Panic:
+				reporter.InstructionIssue(c.severity.Kind(_kindPanic),
+					"workflow code panics, failing the decision task until the workflow is fixed",
+					i, stackTrace, analysis.PathVersionGuards(i, stackTrace))
+
+			case ssa.CallInstruction:
+				if builtin, ok := i.Common().Value.(*ssa.Builtin); ok && builtin.Name() == "recover" {
+					reporter.InstructionIssue(c.severity.Kind(_kindRecover),
+						"recover in workflow code may swallow panics used by Cadence to unwind workflow coroutines",
+						i, stackTrace, analysis.PathVersionGuards(i, stackTrace))
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package queryhandler
+
+import (
+	"fmt"
+	"github.com/sema/cadencecheck/pkg/analysis"
+	"github.com/sema/cadencecheck/pkg/entities"
+	"github.com/sema/cadencecheck/pkg/reporter"
+	"go/token"
+	"go/types"
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/ssa"
+)
+
+const (
+	_kindQueryHandler = "ERROR-QUERY-HANDLER"
+)
+
+// deniedPatterns are workflow APIs which block or generate decisions, neither of which is allowed while answering
+// a query
+var deniedPatterns = []entities.FunctionPattern{
+	{
+		Package: "go.uber.org/cadence/workflow",
+		Type:    "",
+		Method:  "ExecuteActivity",
+	},
+	{
+		Package: "go.uber.org/cadence/workflow",
+		Type:    "",
+		Method:  "ExecuteLocalActivity",
+	},
+	{
+		Package: "go.uber.org/cadence/workflow",
+		Type:    "",
+		Method:  "ExecuteChildWorkflow",
+	},
+	{
+		Package: "go.uber.org/cadence/workflow",
+		Type:    "",
+		Method:  "Sleep",
+	},
+	{
+		Package: "go.uber.org/cadence/workflow",
+		Type:    "",
+		Method:  "NewTimer",
+	},
+	{
+		Package: "go.uber.org/cadence/workflow",
+		Type:    "",
+		Method:  "SignalExternalWorkflow",
+	},
+	{
+		Package: "go.uber.org/cadence/workflow",
+		Type:    "",
+		Method:  "RequestCancelExternalWorkflow",
+	},
+	{
+		Package: "go.uber.org/cadence/workflow",
+		Type:    "",
+		Method:  "SideEffect",
+	},
+	{
+		Package: "go.uber.org/cadence/workflow",
+		Type:    "",
+		Method:  "MutableSideEffect",
+	},
+	{
+		Package: "go.uber.org/cadence/internal",
+		Type:    "Channel",
+		Method:  "Receive",
+	},
+	{
+		Package: "go.uber.org/cadence/internal",
+		Type:    "Channel",
+		Method:  "ReceiveAsync",
+	},
+	{
+		Package: "go.uber.org/cadence/internal",
+		Type:    "Channel",
+		Method:  "ReceiveAsyncWithMoreFlag",
+	},
+	{
+		Package: "go.uber.org/cadence/internal",
+		Type:    "Channel",
+		Method:  "Send",
+	},
+	{
+		Package: "go.uber.org/cadence/internal",
+		Type:    "Selector",
+		Method:  "Select",
+	},
+}
+
+// Check verifies that query handlers registered using workflow.SetQueryHandler only read workflow state
+//
+// Query handlers must not block, generate decisions, receive from channels or write to variables captured from the
+// workflow registering them, whether directly, through pointers, maps and slices read from them, or in the functions
+// they are passed to.
+type Check struct{}
+
+func New() *Check {
+	return &Check{}
+}
+
+func (c *Check) Check(f *ssa.Function, callGraph *callgraph.Graph, reporter *reporter.TerminalReporter) error {
+	root, ok := callGraph.Nodes[f]
+	if !ok {
+		return fmt.Errorf("could not find callgraph for function %s", reporter.FormatFunction(f))
+	}
+
+	for _, callSite := range analysis.FindCallSites(root, deniedPatterns) {
+		reporter.InstructionIssue(_kindQueryHandler, fmt.Sprintf(
+			"query handler calls %s, query handlers must not block or generate decisions", callSite.Signature.String()),
+			callSite.Instruction, callSite.StackTrace, callSite.VersionGuards())
+	}
+
+	analysis.VisitReachableFunctions(root, func(fn *ssa.Function, stackTrace []*callgraph.Edge) {
+		for _, block := range fn.Blocks {
+			for _, instr := range block.Instrs {
+				if isChannelOperation(instr) {
+					reporter.InstructionIssue(_kindQueryHandler,
+						"query handler uses a channel, query handlers must not block",
+						instr, stackTrace, analysis.PathVersionGuards(instr, stackTrace))
+				}
+			}
+		}
+	})
+
+	for _, freeVar := range f.FreeVars {
+		finder := writeFinder{callGraph: callGraph, visited: map[ssa.Value]bool{}}
+		for _, w := range finder.writes(freeVar, nil) {
+			reporter.InstructionIssue(_kindQueryHandler, fmt.Sprintf(
+				"query handler writes to variable %s captured from the workflow, "+
+					"query handlers must not modify workflow state", freeVar.Name()),
+				w.instr, w.stackTrace, analysis.PathVersionGuards(w.instr, w.stackTrace))
+		}
+	}
+
+	return nil
+}
+
+// isChannelOperation returns true for sends, receives and selects on native Go channels
+func isChannelOperation(instr ssa.Instruction) bool {
+	switch i := instr.(type) {
+	case *ssa.Send, *ssa.Select:
+		return true
+	case *ssa.UnOp:
+		return i.Op == token.ARROW
+	default:
+		return false
+	}
+}
+
+// write is an instruction modifying workflow state, reached from the query handler through stackTrace
+type write struct {
+	instr      ssa.Instruction
+	stackTrace []*callgraph.Edge
+}
+
+type writeFinder struct {
+	callGraph *callgraph.Graph
+	visited   map[ssa.Value]bool
+}
+
+// writes returns the instructions writing to the variable addr points to, including writes to its fields, elements
+// and map entries, and writes through pointers, maps and slices loaded from it. Writes by the application functions
+// addr is passed to are followed as well.
+func (w *writeFinder) writes(addr ssa.Value, stackTrace []*callgraph.Edge) []write {
+	if w.visited[addr] {
+		return nil
+	}
+	w.visited[addr] = true
+
+	var result []write
+	for _, r := range *addr.Referrers() {
+		switch instr := r.(type) {
+		case *ssa.Store:
+			if instr.Addr == addr {
+				result = append(result, write{instr, stackTrace})
+			}
+		case *ssa.MapUpdate:
+			if instr.Map == addr {
+				result = append(result, write{instr, stackTrace})
+			}
+		case *ssa.FieldAddr, *ssa.IndexAddr, *ssa.Slice, *ssa.ChangeType:
+			result = append(result, w.writes(instr.(ssa.Value), stackTrace)...)
+		case *ssa.UnOp:
+			// a loaded pointer, map or slice still refers to the workflow state, a loaded value is a copy
+			if instr.Op == token.MUL && isReference(instr.Type()) {
+				result = append(result, w.writes(instr, stackTrace)...)
+			}
+		case *ssa.MakeClosure:
+			for i, binding := range instr.Bindings {
+				if binding == addr {
+					fn := instr.Fn.(*ssa.Function)
+					result = append(result, w.writes(fn.FreeVars[i], stackTrace)...)
+				}
+			}
+		case ssa.CallInstruction:
+			result = append(result, w.calleeWrites(instr, addr, stackTrace)...)
+		}
+	}
+	return result
+}
+
+// calleeWrites returns the writes of the application functions called by call to the parameters addr is passed as
+func (w *writeFinder) calleeWrites(call ssa.CallInstruction, addr ssa.Value, stackTrace []*callgraph.Edge) []write {
+	args := call.Common().Args
+	if call.Common().IsInvoke() {
+		args = append([]ssa.Value{call.Common().Value}, args...)
+	}
+
+	node := w.callGraph.Nodes[call.Parent()]
+	if node == nil {
+		return nil
+	}
+
+	var result []write
+	for _, edge := range node.Out {
+		callee := edge.Callee.Func
+		if edge.Site != call || analysis.IsLibraryFunction(callee) || len(callee.Params) != len(args) {
+			continue
+		}
+
+		for i, arg := range args {
+			if arg == addr {
+				calleeTrace := append(append([]*callgraph.Edge{}, stackTrace...), edge)
+				result = append(result, w.writes(callee.Params[i], calleeTrace)...)
+			}
+		}
+	}
+	return result
+}
+
+// isReference returns true for types whose values refer to shared state when copied
+func isReference(typ types.Type) bool {
+	switch typ.Underlying().(type) {
+	case *types.Pointer, *types.Map, *types.Slice:
+		return true
+	default:
+		return false
+	}
+}
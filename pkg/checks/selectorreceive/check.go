@@ -0,0 +1,167 @@
+package selectorreceive
+
+import (
+	"fmt"
+	"github.com/sema/cadencecheck/pkg/analysis"
+	"github.com/sema/cadencecheck/pkg/entities"
+	"github.com/sema/cadencecheck/pkg/reporter"
+	"go/token"
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/ssa"
+)
+
+const (
+	_kindChannelNotConsumed = "ERROR-CHANNEL-NOT-CONSUMED"
+)
+
+var addReceivePattern = entities.FunctionPattern{
+	Package: "go.uber.org/cadence/internal",
+	Type:    "Selector",
+	Method:  "AddReceive",
+}
+
+// receiveMethods are the methods of workflow.Channel which consume a value
+var receiveMethods = map[string]bool{
+	"Receive":                  true,
+	"ReceiveAsync":             true,
+	"ReceiveAsyncWithMoreFlag": true,
+}
+
+// Check reports callbacks passed to Selector.AddReceive which never receive from the channel. The channel stays
+// ready, so the selector selects the same callback again and the workflow spins forever.
+//
+// Receiving from the channel passed to the callback, or from the channel captured from the enclosing function,
+// counts as consuming it. The channel is followed into application functions it is passed to.
+type Check struct{}
+
+func New() *Check {
+	return &Check{}
+}
+
+func (c *Check) Check(f *ssa.Function, callGraph *callgraph.Graph, reporter *reporter.TerminalReporter) error {
+	root, ok := callGraph.Nodes[f]
+	if !ok {
+		return fmt.Errorf("could not find callgraph for function %s", reporter.FormatFunction(f))
+	}
+
+	for _, callSite := range analysis.FindCallSites(root, []entities.FunctionPattern{addReceivePattern}) {
+		common := callSite.Instruction.Common()
+
+		callbacks, err := analysis.ResolveFunctions(common.Args[1], callGraph, map[ssa.Value]bool{})
+		if err != nil {
+			reporter.Debug("unable to infer callback passed to Selector.AddReceive at %s: %s",
+				reporter.FormatCallSite(callSite.Instruction), err)
+			continue
+		}
+
+		for _, callback := range callbacks {
+			if len(callback.Params) != 2 {
+				continue
+			}
+
+			consumer := consumer{
+				channel: common.Args[0],
+				seen:    map[ssa.Value]bool{},
+			}
+			if consumer.consumes(callback.Params[0]) || consumer.consumesCaptured(callback) {
+				continue
+			}
+
+			reporter.InstructionIssue(_kindChannelNotConsumed, fmt.Sprintf(
+				"callback %s passed to Selector.AddReceive never receives from the channel, "+
+					"the selector will select it again without blocking", callback.RelString(nil)),
+				callSite.Instruction, callSite.StackTrace, callSite.VersionGuards())
+		}
+	}
+
+	return nil
+}
+
+type consumer struct {
+	// channel is the channel passed to Selector.AddReceive
+	channel ssa.Value
+	seen    map[ssa.Value]bool
+}
+
+// consumes returns true if a value is received from channel, directly or by a function it is passed to
+func (c *consumer) consumes(channel ssa.Value) bool {
+	if c.seen[channel] {
+		return false
+	}
+	c.seen[channel] = true
+
+	for _, r := range *channel.Referrers() {
+		call, ok := r.(ssa.CallInstruction)
+		if !ok {
+			continue
+		}
+
+		common := call.Common()
+		if common.IsInvoke() && common.Value == channel && receiveMethods[common.Method.Name()] {
+			return true
+		}
+
+		callee := common.StaticCallee()
+		if callee == nil || analysis.IsLibraryFunction(callee) || len(callee.Blocks) == 0 {
+			continue
+		}
+
+		for i, arg := range common.Args {
+			if arg == channel && c.consumes(callee.Params[i]) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// consumesCaptured returns true if callback receives from the channel captured from the function registering it
+func (c *consumer) consumesCaptured(callback *ssa.Function) bool {
+	for _, closure := range makeClosures(callback) {
+		for i, binding := range closure.Bindings {
+			freeVar := callback.FreeVars[i]
+
+			switch {
+			case binding == c.channel:
+				// captured by value
+				if c.consumes(freeVar) {
+					return true
+				}
+
+			case isLoadOf(c.channel, binding):
+				// captured by reference, each use loads the channel
+				for _, r := range *freeVar.Referrers() {
+					if load, ok := r.(*ssa.UnOp); ok && load.Op == token.MUL && c.consumes(load) {
+						return true
+					}
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// makeClosures returns the MakeClosure instructions creating closures of fn in its parent function
+func makeClosures(fn *ssa.Function) []*ssa.MakeClosure {
+	if fn.Parent() == nil {
+		return nil
+	}
+
+	var result []*ssa.MakeClosure
+	for _, block := range fn.Parent().Blocks {
+		for _, instr := range block.Instrs {
+			if closure, ok := instr.(*ssa.MakeClosure); ok && closure.Fn == fn {
+				result = append(result, closure)
+			}
+		}
+	}
+	return result
+}
+
+// isLoadOf returns true if value is loaded from addr
+func isLoadOf(value ssa.Value, addr ssa.Value) bool {
+	load, ok := value.(*ssa.UnOp)
+	return ok && load.Op == token.MUL && load.X == addr
+}
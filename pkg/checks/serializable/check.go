@@ -0,0 +1,174 @@
+package serializable
+
+import (
+	"fmt"
+	"github.com/sema/cadencecheck/pkg/analysis"
+	"github.com/sema/cadencecheck/pkg/entities"
+	"github.com/sema/cadencecheck/pkg/reporter"
+	"go/types"
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/ssa"
+)
+
+const (
+	_kindNotSerializable = "ERROR-NOT-SERIALIZABLE"
+)
+
+var executePatterns = []entities.FunctionPattern{
+	{
+		Package: "go.uber.org/cadence/workflow",
+		Type:    "",
+		Method:  "ExecuteActivity",
+	},
+	{
+		Package: "go.uber.org/cadence/workflow",
+		Type:    "",
+		Method:  "ExecuteLocalActivity",
+	},
+	{
+		Package: "go.uber.org/cadence/workflow",
+		Type:    "",
+		Method:  "ExecuteChildWorkflow",
+	},
+}
+
+// problem describes a part of a type which does not round-trip through the default (JSON) data converter
+type problem struct {
+	path   string
+	typ    types.Type
+	reason string
+}
+
+// Check reports workflow and activity payloads which are not serializable by Cadence's default data converter
+//
+// Both the signature of the checked function and the concrete types of arguments passed when executing activities
+// and child workflows are checked.
+type Check struct{}
+
+func New() *Check {
+	return &Check{}
+}
+
+func (c *Check) Check(f *ssa.Function, callGraph *callgraph.Graph, reporter *reporter.TerminalReporter) error {
+	root, ok := callGraph.Nodes[f]
+	if !ok {
+		return fmt.Errorf("could not find callgraph for function %s", reporter.FormatFunction(f))
+	}
+
+	for _, param := range analysis.PayloadParams(f.Signature) {
+		for _, p := range findProblems(param.Type(), param.Name(), map[types.Type]bool{}) {
+			reporter.FunctionIssue(_kindNotSerializable, fmt.Sprintf(
+				"parameter %s of %s is not serializable: %s", param.Name(), f.RelString(nil), p), f)
+		}
+	}
+
+	if result := analysis.PayloadResult(f.Signature); result != nil {
+		for _, p := range findProblems(result, "result", map[types.Type]bool{}) {
+			reporter.FunctionIssue(_kindNotSerializable, fmt.Sprintf(
+				"result of %s is not serializable: %s", f.RelString(nil), p), f)
+		}
+	}
+
+	for _, callSite := range analysis.FindCallSites(root, executePatterns) {
+		args, ok := analysis.VariadicArguments(callSite.Instruction.Common().Args[2])
+		if !ok {
+			continue
+		}
+
+		for i, arg := range args {
+			argType, ok := analysis.ConcreteType(arg)
+			if !ok {
+				continue
+			}
+
+			path := fmt.Sprintf("argument %d", i+1)
+			for _, p := range findProblems(argType, path, map[types.Type]bool{}) {
+				reporter.InstructionIssue(_kindNotSerializable, fmt.Sprintf(
+					"argument passed to %s is not serializable: %s", callSite.Signature.Method, p),
+					callSite.Instruction, callSite.StackTrace, callSite.VersionGuards())
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p problem) String() string {
+	return fmt.Sprintf("%s has type %s, %s", p.path, types.TypeString(p.typ, nil), p.reason)
+}
+
+// findProblems walks typ the same way the JSON encoder does, collecting the parts of typ that are lost or rejected
+func findProblems(typ types.Type, path string, seen map[types.Type]bool) []problem {
+	if seen[typ] {
+		return nil // recursive type, already being checked
+	}
+	seen[typ] = true
+	defer delete(seen, typ)
+
+	if analysis.HasCustomJSONEncoding(typ) {
+		return nil
+	}
+
+	switch t := typ.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case t.Info()&types.IsComplex != 0:
+			return []problem{{path, typ, "complex numbers cannot be encoded"}}
+		case t.Kind() == types.UnsafePointer:
+			return []problem{{path, typ, "unsafe pointers cannot be encoded"}}
+		}
+		return nil
+
+	case *types.Chan:
+		return []problem{{path, typ, "channels cannot be encoded"}}
+
+	case *types.Signature:
+		return []problem{{path, typ, "functions cannot be encoded"}}
+
+	case *types.Interface:
+		return []problem{{path, typ, "interface values are decoded without their concrete type"}}
+
+	case *types.Pointer:
+		return findProblems(t.Elem(), path, seen)
+
+	case *types.Slice:
+		return findProblems(t.Elem(), path+"[]", seen)
+
+	case *types.Array:
+		return findProblems(t.Elem(), path+"[]", seen)
+
+	case *types.Map:
+		if !isValidMapKey(t.Key()) {
+			return []problem{{path, typ, "map keys must be strings, integers or implement encoding.TextMarshaler"}}
+		}
+		return findProblems(t.Elem(), path+"[]", seen)
+
+	case *types.Struct:
+		if t.NumFields() == 0 {
+			return nil
+		}
+
+		fields := analysis.JSONFields(t)
+		var result []problem
+		for _, field := range fields {
+			result = append(result, findProblems(field.Field.Type(), path+"."+field.Field.Name(), seen)...)
+		}
+
+		if len(fields) == 0 {
+			return []problem{{path, typ, "struct has no exported fields and is encoded as an empty object"}}
+		}
+		return result
+
+	default:
+		return nil
+	}
+}
+
+func isValidMapKey(typ types.Type) bool {
+	if analysis.HasCustomJSONEncoding(typ) {
+		return true
+	}
+
+	basic, ok := typ.Underlying().(*types.Basic)
+	return ok && basic.Info()&(types.IsString|types.IsInteger) != 0
+}
@@ -0,0 +1,336 @@
+package signalnames
+
+import (
+	"fmt"
+	"github.com/sema/cadencecheck/pkg/analysis"
+	"github.com/sema/cadencecheck/pkg/entities"
+	"github.com/sema/cadencecheck/pkg/reporter"
+	"go/types"
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/ssa"
+)
+
+const (
+	_kindSignalNotReceived = "ERROR-SIGNAL-NOT-RECEIVED"
+	_kindSignalNotSent     = "WARNING-SIGNAL-NOT-SENT"
+	_kindSignalPayload     = "ERROR-SIGNAL-PAYLOAD-MISMATCH"
+	_kindQueryNotHandled   = "ERROR-QUERY-NOT-HANDLED"
+	_kindQueryNotSent      = "WARNING-QUERY-NOT-SENT"
+
+	// _stackTraceQueryType is answered by the Cadence client for every workflow, without a query handler
+	_stackTraceQueryType = "__stack_trace"
+
+	_workflowPackage = "go.uber.org/cadence/workflow"
+	_internalPackage = "go.uber.org/cadence/internal"
+)
+
+// endpointKind describes the name argument, and the payload argument if any, of a function sending or receiving
+// signals or queries
+type endpointKind struct {
+	nameArg int
+	// payloadArg is the index of the payload argument, or -1 if there is none
+	payloadArg int
+}
+
+// signalSenders maps the functions sending signals to their arguments. client.Client is an alias of internal.Client,
+// and the receiver is not part of the arguments of an interface method invocation.
+var signalSenders = map[entities.FunctionPattern]endpointKind{
+	{
+		Package: _workflowPackage,
+		Type:    "",
+		Method:  "SignalExternalWorkflow",
+	}: {nameArg: 3, payloadArg: 4},
+	{
+		Package: _internalPackage,
+		Type:    "Client",
+		Method:  "SignalWorkflow",
+	}: {nameArg: 3, payloadArg: 4},
+	{
+		Package: _internalPackage,
+		Type:    "Client",
+		Method:  "SignalWithStartWorkflow",
+	}: {nameArg: 2, payloadArg: 3},
+}
+
+var signalReceivers = map[entities.FunctionPattern]endpointKind{
+	{
+		Package: _workflowPackage,
+		Type:    "",
+		Method:  "GetSignalChannel",
+	}: {nameArg: 1, payloadArg: -1},
+}
+
+var querySenders = map[entities.FunctionPattern]endpointKind{
+	{
+		Package: _internalPackage,
+		Type:    "Client",
+		Method:  "QueryWorkflow",
+	}: {nameArg: 3, payloadArg: -1},
+}
+
+var queryHandlers = map[entities.FunctionPattern]endpointKind{
+	{
+		Package: _workflowPackage,
+		Type:    "",
+		Method:  "SetQueryHandler",
+	}: {nameArg: 1, payloadArg: -1},
+}
+
+// channelReceives maps the methods receiving from a channel to the index of the value pointer argument
+var channelReceives = map[entities.FunctionPattern]int{
+	{
+		Package: _internalPackage,
+		Type:    "Channel",
+		Method:  "Receive",
+	}: 1,
+	{
+		Package: _internalPackage,
+		Type:    "Channel",
+		Method:  "ReceiveAsync",
+	}: 0,
+	{
+		Package: _internalPackage,
+		Type:    "Channel",
+		Method:  "ReceiveAsyncWithMoreFlag",
+	}: 0,
+}
+
+// selectorAddReceive registers a handler called with the channel passed as the first argument
+var selectorAddReceive = entities.FunctionPattern{
+	Package: _internalPackage,
+	Type:    "Selector",
+	Method:  "AddReceive",
+}
+
+// Check reports signals and queries whose names are used by only one side, e.g. a signal sent by a client but never
+// received by a workflow, and signals sent with a payload the receiving workflow can't decode
+//
+// Payloads are compared by the shape of their JSON encoding, see analysis.JSONCompatible.
+//
+// Names are matched across all analyzed packages. Names used by one side are only reported if the other side is part
+// of the analyzed program, i.e. it contains at least one call of the other side, and all names of the other side are
+// constant.
+type Check struct{}
+
+func New() *Check {
+	return &Check{}
+}
+
+// endpoint is a call sending or receiving a signal or query
+type endpoint struct {
+	name    string
+	call    ssa.CallInstruction
+	payload ssa.Value
+}
+
+// endpoints are the calls of one side of either signals or queries
+type endpoints struct {
+	calls []endpoint
+	names map[string]bool
+	// unresolved is set if the name of some call is not constant, which may then be any name
+	unresolved bool
+}
+
+func (e *endpoints) add(call ssa.CallInstruction, kind endpointKind) {
+	if e.names == nil {
+		e.names = map[string]bool{}
+	}
+
+	args := call.Common().Args
+	name, ok := analysis.ConstString(args[kind.nameArg])
+	if !ok {
+		e.unresolved = true
+		return
+	}
+
+	ep := endpoint{name: name, call: call}
+	if kind.payloadArg >= 0 {
+		ep.payload = args[kind.payloadArg]
+	}
+	e.calls = append(e.calls, ep)
+	e.names[name] = true
+}
+
+// present returns true if the other side can be compared against these endpoints
+func (e *endpoints) present() bool {
+	return len(e.calls) > 0 && !e.unresolved
+}
+
+// received is a value pointer passed to a receive call on a signal channel
+type received struct {
+	call  ssa.CallInstruction
+	value ssa.Value
+}
+
+func (c *Check) CheckProgram(
+	entrypoints analysis.Entrypoints,
+	callGraph *callgraph.Graph,
+	reporter *reporter.TerminalReporter,
+) error {
+	var signalsSent, signalsReceived, queriesSent, queriesHandled endpoints
+	var receives []received
+	selectorChannels := map[*ssa.Function][]ssa.Value{}
+
+	for _, f := range analysis.SortedFunctions(callGraph) {
+		if analysis.IsLibraryFunction(f) {
+			continue
+		}
+
+		for _, block := range f.Blocks {
+			for _, instr := range block.Instrs {
+				call, ok := instr.(ssa.CallInstruction)
+				if !ok {
+					continue
+				}
+
+				signature, err := analysis.CallSignature(call.Common())
+				if err != nil {
+					continue
+				}
+
+				if kind, ok := signalSenders[signature]; ok {
+					signalsSent.add(call, kind)
+				}
+				if kind, ok := signalReceivers[signature]; ok {
+					signalsReceived.add(call, kind)
+				}
+				if kind, ok := querySenders[signature]; ok {
+					queriesSent.add(call, kind)
+				}
+				if kind, ok := queryHandlers[signature]; ok {
+					queriesHandled.add(call, kind)
+				}
+				if idx, ok := channelReceives[signature]; ok {
+					receives = append(receives, received{call: call, value: call.Common().Args[idx]})
+				}
+				if signature == selectorAddReceive {
+					if handler := handlerFunction(call.Common().Args[1]); handler != nil {
+						selectorChannels[handler] = append(selectorChannels[handler], call.Common().Args[0])
+					}
+				}
+			}
+		}
+	}
+
+	if signalsReceived.present() {
+		for _, sent := range signalsSent.calls {
+			if !signalsReceived.names[sent.name] {
+				reporter.InstructionIssue(_kindSignalNotReceived, fmt.Sprintf(
+					"signal %q is sent, but never received by a workflow", sent.name),
+					sent.call, nil, analysis.PathVersionGuards(sent.call, nil))
+			}
+		}
+	}
+	if signalsSent.present() {
+		for _, receiver := range signalsReceived.calls {
+			if !signalsSent.names[receiver.name] {
+				reporter.InstructionIssue(_kindSignalNotSent, fmt.Sprintf(
+					"signal %q is received, but never sent by a client or workflow", receiver.name),
+					receiver.call, nil, analysis.PathVersionGuards(receiver.call, nil))
+			}
+		}
+	}
+	if queriesHandled.present() {
+		for _, sent := range queriesSent.calls {
+			if !queriesHandled.names[sent.name] && sent.name != _stackTraceQueryType {
+				reporter.InstructionIssue(_kindQueryNotHandled, fmt.Sprintf(
+					"query %q is sent, but never handled by a workflow", sent.name),
+					sent.call, nil, analysis.PathVersionGuards(sent.call, nil))
+			}
+		}
+	}
+	if queriesSent.present() {
+		for _, handler := range queriesHandled.calls {
+			if !queriesSent.names[handler.name] {
+				reporter.InstructionIssue(_kindQueryNotSent, fmt.Sprintf(
+					"query %q is handled, but never sent by a client", handler.name),
+					handler.call, nil, analysis.PathVersionGuards(handler.call, nil))
+			}
+		}
+	}
+
+	receivedTypes := map[string][]types.Type{}
+	for _, r := range receives {
+		target, ok := receiveTarget(r.value)
+		if !ok {
+			continue
+		}
+		for _, name := range channelNames(r.call.Common().Value, selectorChannels) {
+			receivedTypes[name] = append(receivedTypes[name], target)
+		}
+	}
+
+	for _, sent := range signalsSent.calls {
+		payload, ok := analysis.ConcreteType(sent.payload)
+		if !ok {
+			continue
+		}
+		for _, target := range receivedTypes[sent.name] {
+			if !analysis.JSONCompatible(payload, target) {
+				reporter.InstructionIssue(_kindSignalPayload, fmt.Sprintf(
+					"signal %q is sent with a payload of type %s, whose JSON encoding can't be decoded into a value "+
+						"of type %s",
+					sent.name, payload, target),
+					sent.call, nil, analysis.PathVersionGuards(sent.call, nil))
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// handlerFunction returns the function passed as a selector handler, or nil if it is not known
+func handlerFunction(value ssa.Value) *ssa.Function {
+	switch v := value.(type) {
+	case *ssa.Function:
+		return v
+	case *ssa.MakeClosure:
+		if f, ok := v.Fn.(*ssa.Function); ok {
+			return f
+		}
+	}
+	return nil
+}
+
+// channelNames returns the names of the signal channels a channel value may hold. Channels are either returned by
+// workflow.GetSignalChannel directly, or passed to a handler of a selector.
+func channelNames(value ssa.Value, selectorChannels map[*ssa.Function][]ssa.Value) []string {
+	switch v := value.(type) {
+	case *ssa.Call:
+		signature, err := analysis.CallSignature(v.Common())
+		if err != nil {
+			return nil
+		}
+		if kind, ok := signalReceivers[signature]; ok {
+			if name, ok := analysis.ConstString(v.Call.Args[kind.nameArg]); ok {
+				return []string{name}
+			}
+		}
+	case *ssa.Parameter:
+		f := v.Parent()
+		if len(f.Params) == 0 || f.Params[0] != v {
+			return nil
+		}
+
+		var result []string
+		for _, channel := range selectorChannels[f] {
+			result = append(result, channelNames(channel, selectorChannels)...)
+		}
+		return result
+	}
+	return nil
+}
+
+// receiveTarget returns the type of the value a receive call decodes into, given the value pointer passed to it
+func receiveTarget(value ssa.Value) (types.Type, bool) {
+	typ, ok := analysis.ConcreteType(value)
+	if !ok {
+		return nil, false
+	}
+	pointer, ok := typ.Underlying().(*types.Pointer)
+	if !ok {
+		return nil, false
+	}
+	return pointer.Elem(), true
+}
@@ -0,0 +1,168 @@
+package stdcontext
+
+import (
+	"fmt"
+	"github.com/sema/cadencecheck/pkg/analysis"
+	"github.com/sema/cadencecheck/pkg/entities"
+	"github.com/sema/cadencecheck/pkg/reporter"
+	"go/types"
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/ssa"
+)
+
+const (
+	_kindStdContext = "ERROR-STD-CONTEXT-IN-WORKFLOW"
+
+	_contextPackage = "context"
+)
+
+// sideEffectCallbacks maps the functions running a callback outside of the deterministic workflow execution, in
+// which a context.Context may be used freely, to the index of the callback argument
+var sideEffectCallbacks = map[entities.FunctionPattern]int{
+	{
+		Package: "go.uber.org/cadence/workflow",
+		Type:    "",
+		Method:  "SideEffect",
+	}: 1,
+	{
+		Package: "go.uber.org/cadence/workflow",
+		Type:    "",
+		Method:  "MutableSideEffect",
+	}: 2,
+}
+
+// Check reports creation of context.Context values in workflows, and context.Context values passed to helpers
+// called from workflows. Such contexts carry timers and cancellation invisible to Cadence, and can't be used with
+// workflow APIs.
+//
+// Contexts created within SideEffect and MutableSideEffect callbacks are only reported if they leave the callback
+// through a captured variable.
+type Check struct{}
+
+func New() *Check {
+	return &Check{}
+}
+
+func (c *Check) Check(f *ssa.Function, callGraph *callgraph.Graph, reporter *reporter.TerminalReporter) error {
+	root, ok := callGraph.Nodes[f]
+	if !ok {
+		return fmt.Errorf("could not find callgraph for function %s", reporter.FormatFunction(f))
+	}
+
+	var patterns []entities.FunctionPattern
+	for p := range sideEffectCallbacks {
+		patterns = append(patterns, p)
+	}
+
+	sideEffects := map[*ssa.Function]bool{}
+	for _, callSite := range analysis.FindCallSites(root, patterns) {
+		callbackArg := callSite.Instruction.Common().Args[sideEffectCallbacks[callSite.Signature]]
+
+		callbacks, err := analysis.ResolveFunctions(callbackArg, callGraph, map[ssa.Value]bool{})
+		if err != nil {
+			reporter.Debug("unable to infer callback passed to %s at %s: %s",
+				callSite.Signature.Method, reporter.FormatCallSite(callSite.Instruction), err)
+			continue
+		}
+
+		for _, callback := range callbacks {
+			sideEffects[callback] = true
+			c.checkEscapes(callback, callSite, reporter)
+		}
+	}
+
+	analysis.VisitReachableFunctions(root, func(fn *ssa.Function, stackTrace []*callgraph.Edge) {
+		if withinSideEffect(fn, sideEffects) {
+			return
+		}
+
+		for _, block := range fn.Blocks {
+			for _, instr := range block.Instrs {
+				if call, ok := instr.(ssa.CallInstruction); ok {
+					c.checkCall(call, stackTrace, reporter)
+				}
+			}
+		}
+	})
+
+	return nil
+}
+
+func (c *Check) checkCall(call ssa.CallInstruction, stackTrace []*callgraph.Edge, reporter *reporter.TerminalReporter) {
+	callee := call.Common().StaticCallee()
+	if callee == nil {
+		return
+	}
+
+	if isContextFunction(callee) {
+		reporter.InstructionIssue(_kindStdContext, fmt.Sprintf(
+			"workflow creates a context.Context using %s, use workflow.Context instead", callee.RelString(nil)),
+			call, stackTrace, analysis.PathVersionGuards(call, stackTrace))
+		return
+	}
+
+	if analysis.IsLibraryFunction(callee) {
+		return
+	}
+
+	for _, arg := range call.Common().Args {
+		if !analysis.IsContext(arg.Type()) || isReportedAtOrigin(arg) {
+			continue
+		}
+
+		reporter.InstructionIssue(_kindStdContext, fmt.Sprintf(
+			"workflow passes a context.Context to %s, use workflow.Context instead", callee.RelString(nil)),
+			call, stackTrace, analysis.PathVersionGuards(call, stackTrace))
+	}
+}
+
+// checkEscapes reports context.Context values stored to variables captured by a SideEffect callback
+func (c *Check) checkEscapes(callback *ssa.Function, callSite analysis.CallSite, reporter *reporter.TerminalReporter) {
+	for _, freeVar := range callback.FreeVars {
+		ptr, ok := freeVar.Type().Underlying().(*types.Pointer)
+		if !ok || !analysis.IsContext(ptr.Elem()) {
+			continue
+		}
+
+		for _, r := range *freeVar.Referrers() {
+			if store, ok := r.(*ssa.Store); ok && store.Addr == freeVar {
+				reporter.InstructionIssue(_kindStdContext, fmt.Sprintf(
+					"context.Context leaves the %s callback through captured variable %s",
+					callSite.Signature.Method, freeVar.Name()),
+					store, callSite.StackTrace, analysis.PathVersionGuards(store, callSite.StackTrace))
+			}
+		}
+	}
+}
+
+// withinSideEffect returns true if fn is a side effect callback, or a closure defined within one
+func withinSideEffect(fn *ssa.Function, sideEffects map[*ssa.Function]bool) bool {
+	for ; fn != nil; fn = fn.Parent() {
+		if sideEffects[fn] {
+			return true
+		}
+	}
+	return false
+}
+
+func isContextFunction(f *ssa.Function) bool {
+	return f.Pkg != nil && f.Pkg.Pkg.Path() == _contextPackage && f.Signature.Recv() == nil
+}
+
+// isReportedAtOrigin returns true for contexts reported where they are created by the context package, or passed
+// on from the caller which is reported instead
+func isReportedAtOrigin(value ssa.Value) bool {
+	if extract, ok := value.(*ssa.Extract); ok {
+		value = extract.Tuple
+	}
+
+	switch v := value.(type) {
+	case *ssa.Parameter:
+		return true
+	case *ssa.Call:
+		callee := v.Call.StaticCallee()
+		return callee != nil && isContextFunction(callee)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,173 @@
+package unusedregistrations
+
+import (
+	"fmt"
+	"github.com/sema/cadencecheck/pkg/analysis"
+	"github.com/sema/cadencecheck/pkg/entities"
+	"github.com/sema/cadencecheck/pkg/reporter"
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/ssa"
+)
+
+const (
+	_kindUnusedActivity = "WARNING-UNUSED-ACTIVITY"
+	_kindUnusedWorkflow = "WARNING-UNUSED-WORKFLOW"
+)
+
+// activityExecutions maps the functions executing activities to the index of the activity argument
+var activityExecutions = map[entities.FunctionPattern]int{
+	{
+		Package: "go.uber.org/cadence/workflow",
+		Type:    "",
+		Method:  "ExecuteActivity",
+	}: 1,
+	{
+		Package: "go.uber.org/cadence/workflow",
+		Type:    "",
+		Method:  "ExecuteLocalActivity",
+	}: 1,
+}
+
+// workflowExecutions maps the functions starting workflows to the index of the workflow argument. client.Client is an
+// alias of internal.Client, and the receiver is not part of the arguments of an interface method invocation.
+var workflowExecutions = map[entities.FunctionPattern]int{
+	{
+		Package: "go.uber.org/cadence/workflow",
+		Type:    "",
+		Method:  "ExecuteChildWorkflow",
+	}: 1,
+	{
+		Package: "go.uber.org/cadence/internal",
+		Type:    "Client",
+		Method:  "StartWorkflow",
+	}: 2,
+	{
+		Package: "go.uber.org/cadence/internal",
+		Type:    "Client",
+		Method:  "ExecuteWorkflow",
+	}: 2,
+	{
+		Package: "go.uber.org/cadence/internal",
+		Type:    "Client",
+		Method:  "SignalWithStartWorkflow",
+	}: 5,
+}
+
+// Check reports registered activities which are never executed, and registered workflows which are never started,
+// by code in the analyzed program
+//
+// Workflows and activities are matched by function, or by the name they are registered under. If any execution can't
+// be resolved, no activities respectively workflows are reported.
+type Check struct{}
+
+func New() *Check {
+	return &Check{}
+}
+
+func (c *Check) CheckProgram(
+	entrypoints analysis.Entrypoints,
+	callGraph *callgraph.Graph,
+	reporter *reporter.TerminalReporter,
+) error {
+	activities := newUsage(entrypoints)
+	workflows := newUsage(entrypoints)
+
+	for _, f := range analysis.SortedFunctions(callGraph) {
+		if analysis.IsLibraryFunction(f) {
+			continue
+		}
+
+		for _, block := range f.Blocks {
+			for _, instr := range block.Instrs {
+				call, ok := instr.(ssa.CallInstruction)
+				if !ok {
+					continue
+				}
+
+				signature, err := analysis.CallSignature(call.Common())
+				if err != nil {
+					continue
+				}
+
+				if idx, ok := activityExecutions[signature]; ok {
+					activities.markUsed(call.Common().Args[idx], callGraph, reporter)
+				}
+				if idx, ok := workflowExecutions[signature]; ok {
+					workflows.markUsed(call.Common().Args[idx], callGraph, reporter)
+				}
+			}
+		}
+	}
+
+	for _, f := range activities.unused(entrypoints.Activities) {
+		reporter.FunctionIssue(_kindUnusedActivity, fmt.Sprintf(
+			"activity %s is registered but never executed by a workflow", activities.name(f)), f)
+	}
+	for _, f := range workflows.unused(entrypoints.Workflows) {
+		reporter.FunctionIssue(_kindUnusedWorkflow, fmt.Sprintf(
+			"workflow %s is registered but never started by a client or parent workflow", workflows.name(f)), f)
+	}
+
+	return nil
+}
+
+// usage records the functions and names executed for either activities or workflows
+type usage struct {
+	registeredNames map[*ssa.Function]string
+	functions       map[*ssa.Function]bool
+	names           map[string]bool
+	// unresolved is set if some execution could not be resolved, which may execute any function
+	unresolved bool
+}
+
+func newUsage(entrypoints analysis.Entrypoints) *usage {
+	return &usage{
+		registeredNames: entrypoints.RegisteredNames,
+		functions:       map[*ssa.Function]bool{},
+		names:           map[string]bool{},
+	}
+}
+
+func (u *usage) markUsed(value ssa.Value, callGraph *callgraph.Graph, reporter *reporter.TerminalReporter) {
+	if iface, ok := value.(*ssa.MakeInterface); ok {
+		value = iface.X // functions and names are passed as interface{}
+	}
+
+	if name, ok := analysis.ConstString(value); ok {
+		u.names[name] = true
+		return
+	}
+
+	fns, err := analysis.ResolveFunctions(value, callGraph, map[ssa.Value]bool{})
+	if err != nil {
+		reporter.Debug("unable to infer function executed by %s: %s", value.Name(), err)
+		u.unresolved = true
+		return
+	}
+	for _, f := range fns {
+		u.functions[f] = true
+	}
+}
+
+// unused returns the registered functions which are neither executed by function nor by name
+func (u *usage) unused(registered []*ssa.Function) []*ssa.Function {
+	if u.unresolved {
+		return nil
+	}
+
+	var result []*ssa.Function
+	for _, f := range registered {
+		if !u.functions[f] && !u.names[u.name(f)] {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+// name returns the name f is registered under
+func (u *usage) name(f *ssa.Function) string {
+	if name, ok := u.registeredNames[f]; ok {
+		return name
+	}
+	return f.RelString(nil)
+}
@@ -0,0 +1,231 @@
+package versioning
+
+import (
+	"fmt"
+	"github.com/sema/cadencecheck/pkg/analysis"
+	"github.com/sema/cadencecheck/pkg/entities"
+	"github.com/sema/cadencecheck/pkg/reporter"
+	"go/token"
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/ssa"
+	"sort"
+	"strings"
+)
+
+const (
+	_kindChangeIDNotConstant = "ERROR-VERSION-CHANGE-ID-NOT-CONSTANT"
+	_kindDuplicateChangeID   = "ERROR-VERSION-DUPLICATE-CHANGE-ID"
+	_kindReusedChangeID      = "WARNING-VERSION-DUPLICATE-CHANGE-ID"
+	_kindInvalidRange        = "ERROR-VERSION-INVALID-RANGE"
+	_kindOutOfRange          = "ERROR-VERSION-OUT-OF-RANGE"
+)
+
+// comparisons are the operators comparing the version returned by GetVersion against a constant
+var comparisons = map[token.Token]bool{
+	token.EQL: true,
+	token.NEQ: true,
+	token.LSS: true,
+	token.LEQ: true,
+	token.GTR: true,
+	token.GEQ: true,
+}
+
+// Check validates the calls to workflow.GetVersion reachable from a workflow, and lists the change IDs in use
+//
+// Change IDs must be constant, minSupported must not exceed maxSupported, and the returned version must only be
+// compared against versions within the declared range. Non-constant versions are not validated.
+//
+// A change ID may be reused by several calls, which return the version recorded by the first, so the calls can't be
+// versioned separately. Every reuse is reported, as an error if the ranges disagree and both calls may run in the same
+// execution, as the recorded version may then be outside the range of the later call, which panics.
+type Check struct{}
+
+func New() *Check {
+	return &Check{}
+}
+
+type change struct {
+	id       string
+	min, max int64
+	ranged   bool
+	callSite analysis.CallSite
+}
+
+func (c *Check) Check(f *ssa.Function, callGraph *callgraph.Graph, reporter *reporter.TerminalReporter) error {
+	root, ok := callGraph.Nodes[f]
+	if !ok {
+		return fmt.Errorf("could not find callgraph for function %s", reporter.FormatFunction(f))
+	}
+
+	changes := map[string][]change{}
+	for _, callSite := range analysis.FindCallSites(root, []entities.FunctionPattern{analysis.GetVersionPattern}) {
+		args := callSite.Instruction.Common().Args
+
+		id, ok := analysis.ConstString(args[1])
+		if !ok {
+			reporter.InstructionIssue(_kindChangeIDNotConstant,
+				"change ID passed to GetVersion is not a constant, and may differ between replays",
+				callSite.Instruction, callSite.StackTrace, callSite.VersionGuards())
+			continue
+		}
+
+		current := change{id: id, callSite: callSite}
+		min, minOk := analysis.ConstInt(args[2])
+		max, maxOk := analysis.ConstInt(args[3])
+		if minOk && maxOk {
+			current.min, current.max, current.ranged = min, max, true
+		}
+
+		c.checkReuse(current, changes[id], reporter)
+		changes[id] = append(changes[id], current)
+
+		if !current.ranged {
+			continue
+		}
+
+		if current.min > current.max {
+			reporter.InstructionIssue(_kindInvalidRange, fmt.Sprintf(
+				"GetVersion for change ID %q has minSupported %d greater than maxSupported %d",
+				id, current.min, current.max),
+				callSite.Instruction, callSite.StackTrace, callSite.VersionGuards())
+			continue
+		}
+
+		c.checkComparisons(current, reporter)
+	}
+
+	if len(changes) > 0 {
+		reporter.Info("active change IDs: %s", formatChanges(changes))
+	}
+
+	return nil
+}
+
+// checkReuse reports a change ID used by an earlier call, as an error if the ranges of the calls conflict
+func (c *Check) checkReuse(current change, previous []change, reporter *reporter.TerminalReporter) {
+	if len(previous) == 0 {
+		return
+	}
+
+	callSite := current.callSite
+	for _, p := range previous {
+		if !conflicting(p, current) {
+			continue
+		}
+
+		reporter.InstructionIssue(_kindDuplicateChangeID, fmt.Sprintf(
+			"change ID %q is also used by GetVersion at %s with supported range %d..%d, which differs from "+
+				"%d..%d, the version recorded by one call may be rejected by the other",
+			current.id, reporter.FormatCallSite(p.callSite.Instruction), p.min, p.max, current.min, current.max),
+			callSite.Instruction, callSite.StackTrace, callSite.VersionGuards())
+		return
+	}
+
+	reporter.InstructionIssue(_kindReusedChangeID, fmt.Sprintf(
+		"change ID %q is also used by GetVersion at %s, the calls share the recorded version and can't be "+
+			"versioned separately",
+		current.id, reporter.FormatCallSite(previous[0].callSite.Instruction)),
+		callSite.Instruction, callSite.StackTrace, callSite.VersionGuards())
+}
+
+// conflicting returns true if both calls have constant, differing ranges, and may run in the same execution
+func conflicting(a change, b change) bool {
+	if !a.ranged || !b.ranged || (a.min == b.min && a.max == b.max) {
+		return false
+	}
+	return !exclusive(a.callSite, b.callSite)
+}
+
+// exclusive returns true if the two calls are made on mutually exclusive branches, i.e. where the paths from the root
+// to the calls diverge, neither instruction can reach the other
+func exclusive(a analysis.CallSite, b analysis.CallSite) bool {
+	pathA, pathB := callPath(a), callPath(b)
+	for i := 0; i < len(pathA) && i < len(pathB); i++ {
+		if pathA[i] == pathB[i] {
+			continue
+		}
+		if pathA[i] == nil || pathB[i] == nil {
+			return false
+		}
+		return !reaches(pathA[i], pathB[i]) && !reaches(pathB[i], pathA[i])
+	}
+	return false
+}
+
+// callPath returns the call instructions leading from the root function to the call
+func callPath(callSite analysis.CallSite) []ssa.Instruction {
+	var result []ssa.Instruction
+	for _, edge := range callSite.StackTrace {
+		result = append(result, edge.Site)
+	}
+	return append(result, callSite.Instruction)
+}
+
+// reaches returns true if to may run after from within the same invocation of their function
+func reaches(from ssa.Instruction, to ssa.Instruction) bool {
+	if from.Parent() != to.Parent() {
+		return true // diverged in different functions, e.g. through differing call graph edges
+	}
+
+	seen := map[*ssa.BasicBlock]bool{}
+	queue := []*ssa.BasicBlock{from.Block()}
+	for len(queue) > 0 {
+		b := queue[0]
+		queue = queue[1:]
+		if b == to.Block() {
+			return true
+		}
+		for _, succ := range b.Succs {
+			if !seen[succ] {
+				seen[succ] = true
+				queue = append(queue, succ)
+			}
+		}
+	}
+	return false
+}
+
+// checkComparisons reports comparisons of the version returned by GetVersion against constants outside its range
+func (c *Check) checkComparisons(current change, reporter *reporter.TerminalReporter) {
+	result := current.callSite.Instruction.Value()
+	if result == nil {
+		return
+	}
+
+	for _, r := range *result.Referrers() {
+		binOp, ok := r.(*ssa.BinOp)
+		if !ok || !comparisons[binOp.Op] {
+			continue
+		}
+
+		other := binOp.Y
+		if other == ssa.Value(result) {
+			other = binOp.X
+		}
+
+		version, ok := analysis.ConstInt(other)
+		if !ok || (version >= current.min && version <= current.max) {
+			continue
+		}
+
+		reporter.InstructionIssue(_kindOutOfRange, fmt.Sprintf(
+			"version of change ID %q is compared against %d, outside of the supported range %d..%d",
+			current.id, version, current.min, current.max),
+			binOp, current.callSite.StackTrace, analysis.PathVersionGuards(binOp, current.callSite.StackTrace))
+	}
+}
+
+func formatChanges(changes map[string][]change) string {
+	var parts []string
+	for id, calls := range changes {
+		ch := calls[0]
+		if ch.ranged {
+			parts = append(parts, fmt.Sprintf("%s (%d..%d)", id, ch.min, ch.max))
+		} else {
+			parts = append(parts, id)
+		}
+	}
+
+	sort.Strings(parts)
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,75 @@
+package commands
+
+// Changes are the command sequences of a workflow which differ between two revisions, and are not guarded by a
+// workflow.GetVersion call introduced by the newer revision
+type Changes struct {
+	// Added are sequences produced by the newer revision only
+	Added []Sequence
+	// Removed are sequences produced by the older revision only
+	Removed []Sequence
+}
+
+// Compare returns the unguarded changes between the command sequences of a workflow in a base and a head revision
+//
+// A change ID passed to GetVersion in head but not in base guards the sequences it is part of, which may then differ
+// from the sequences of base. GetVersion calls for such change IDs are ignored when comparing sequences, so the
+// branch for workflow.DefaultVersion must still produce the sequences of base.
+func Compare(base []Sequence, head []Sequence) Changes {
+	baseIDs := changeIDs(base)
+
+	newIDs := map[string]bool{}
+	for id := range changeIDs(head) {
+		if !baseIDs[id] {
+			newIDs[id] = true
+		}
+	}
+
+	baseKeys := map[string]bool{}
+	for _, s := range base {
+		baseKeys[s.String()] = true
+	}
+
+	headKeys := map[string]bool{}
+	var changes Changes
+	for _, s := range head {
+		stripped := withoutChangeIDs(s, newIDs)
+		headKeys[stripped.String()] = true
+
+		if !baseKeys[stripped.String()] && len(stripped) == len(s) {
+			changes.Added = append(changes.Added, s)
+		}
+	}
+
+	for _, s := range base {
+		if !headKeys[s.String()] {
+			changes.Removed = append(changes.Removed, s)
+		}
+	}
+
+	return changes
+}
+
+// changeIDs returns the change IDs passed to GetVersion in sequences
+func changeIDs(sequences []Sequence) map[string]bool {
+	result := map[string]bool{}
+	for _, s := range sequences {
+		for _, c := range s {
+			if c.Kind == "GetVersion" {
+				result[c.Target] = true
+			}
+		}
+	}
+	return result
+}
+
+// withoutChangeIDs returns sequence without the GetVersion commands for the given change IDs
+func withoutChangeIDs(sequence Sequence, ids map[string]bool) Sequence {
+	var result Sequence
+	for _, c := range sequence {
+		if c.Kind == "GetVersion" && ids[c.Target] {
+			continue
+		}
+		result = append(result, c)
+	}
+	return result
+}
@@ -0,0 +1,48 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteJSON writes the models as an indented JSON array
+func WriteJSON(w io.Writer, models []Model) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(models)
+}
+
+// WriteDOT writes the models as a Graphviz digraph, with a cluster per workflow
+func WriteDOT(w io.Writer, models []Model) error {
+	var b strings.Builder
+
+	b.WriteString("digraph workflows {\n")
+	b.WriteString("\tnode [shape=box];\n")
+	for i, model := range models {
+		fmt.Fprintf(&b, "\tsubgraph cluster_%d {\n", i)
+		fmt.Fprintf(&b, "\t\tlabel=%s;\n", quoteDOT(model.Workflow))
+
+		for _, node := range model.Nodes {
+			shape := ""
+			if node.Kind == StartNode || node.Kind == EndNode {
+				shape = ", shape=ellipse"
+			}
+			fmt.Fprintf(&b, "\t\tw%d_n%d [label=%s%s];\n", i, node.ID, quoteDOT(node.Label()), shape)
+		}
+		for _, edge := range model.Edges {
+			fmt.Fprintf(&b, "\t\tw%d_n%d -> w%d_n%d;\n", i, edge.From, i, edge.To)
+		}
+
+		b.WriteString("\t}\n")
+	}
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func quoteDOT(s string) string {
+	return `"` + strings.Replace(strings.Replace(s, `\`, `\\`, -1), `"`, `\"`, -1) + `"`
+}
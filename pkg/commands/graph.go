@@ -0,0 +1,221 @@
+package commands
+
+import (
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/ssa"
+)
+
+const (
+	// _maxVertices caps the size of the graph built while inlining helpers into a workflow
+	_maxVertices = 2000
+
+	StartNode = "Start"
+	EndNode   = "End"
+)
+
+// Model is the state machine of the commands a workflow can issue, including signal and query handling set up by it
+//
+// An edge from one node to another means the second command may be issued next. Branches show as nodes with
+// several outgoing edges, and loops as cycles.
+type Model struct {
+	Workflow string `json:"workflow"`
+	Nodes    []Node `json:"nodes"`
+	Edges    []Edge `json:"edges"`
+	// Truncated is set if the workflow is too large to inline all helpers
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// Node is a command issued at a call site, or the start or end of the workflow
+type Node struct {
+	ID       int    `json:"id"`
+	Kind     string `json:"kind"`
+	Target   string `json:"target,omitempty"`
+	Position string `json:"position,omitempty"`
+}
+
+func (n Node) Label() string {
+	if n.Target == "" {
+		return n.Kind
+	}
+	return n.Kind + "(" + n.Target + ")"
+}
+
+type Edge struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+}
+
+// BuildModel derives the command state machine of the workflow f from the control-flow graph of f, inlining the
+// application functions it calls. Callbacks run by the Cadence client, e.g. passed to workflow.Go, are not followed.
+func BuildModel(f *ssa.Function, callGraph *callgraph.Graph) Model {
+	b := modelBuilder{
+		callGraph: callGraph,
+		active:    map[*ssa.Function]bool{},
+	}
+
+	entry, exit := b.function(f)
+	b.vertices[entry].node = &Node{Kind: StartNode}
+	b.vertices[exit].node = &Node{Kind: EndNode}
+
+	return b.collapse(f, entry)
+}
+
+// vertex is a point in the inlined control-flow graph, which either issues a command or is only passed through
+type vertex struct {
+	node  *Node
+	succs []int
+}
+
+type modelBuilder struct {
+	callGraph *callgraph.Graph
+	active    map[*ssa.Function]bool
+	vertices  []vertex
+	truncated bool
+}
+
+func (b *modelBuilder) add(node *Node) int {
+	b.vertices = append(b.vertices, vertex{node: node})
+	return len(b.vertices) - 1
+}
+
+func (b *modelBuilder) connect(from int, to int) {
+	b.vertices[from].succs = append(b.vertices[from].succs, to)
+}
+
+// function inlines a copy of the control-flow graph of f, and returns its entry and exit vertices
+func (b *modelBuilder) function(f *ssa.Function) (int, int) {
+	entry, exit := b.add(nil), b.add(nil)
+	if b.active[f] || len(f.Blocks) == 0 {
+		b.connect(entry, exit) // recursion, or a function without a body
+		return entry, exit
+	}
+	if len(b.vertices) > _maxVertices {
+		b.truncated = true
+		b.connect(entry, exit)
+		return entry, exit
+	}
+
+	b.active[f] = true
+	defer delete(b.active, f)
+
+	blocks := map[*ssa.BasicBlock]int{}
+	for _, block := range f.Blocks {
+		blocks[block] = b.add(nil)
+	}
+	b.connect(entry, blocks[f.Blocks[0]])
+
+	for _, block := range f.Blocks {
+		current := blocks[block]
+		for _, instr := range block.Instrs {
+			call, ok := instr.(*ssa.Call)
+			if !ok {
+				continue
+			}
+			current = b.call(call, current)
+		}
+
+		if len(block.Succs) == 0 {
+			b.connect(current, exit)
+		}
+		for _, succ := range block.Succs {
+			b.connect(current, blocks[succ])
+		}
+	}
+
+	return entry, exit
+}
+
+// call adds the commands issued by call after the vertex current, and returns the vertex reached after the call
+func (b *modelBuilder) call(call *ssa.Call, current int) int {
+	if command, ok := b.command(call); ok {
+		position := call.Parent().Prog.Fset.Position(call.Pos())
+		v := b.add(&Node{Kind: command.Kind, Target: command.Target, Position: position.String()})
+		b.connect(current, v)
+		return v
+	}
+
+	callees := applicationCallees(call, b.callGraph)
+	if len(callees) == 0 {
+		return current
+	}
+
+	join := b.add(nil)
+	for _, callee := range callees {
+		entry, exit := b.function(callee)
+		b.connect(current, entry)
+		b.connect(exit, join)
+	}
+	return join
+}
+
+func (b *modelBuilder) command(call *ssa.Call) (Command, bool) {
+	if command, ok := findCommand(call, decisionFunctions, b.callGraph); ok {
+		return command, true
+	}
+	return findCommand(call, handlerFunctions, b.callGraph)
+}
+
+// collapse removes the vertices which don't issue commands, connecting each command to the commands reachable from
+// it through such vertices
+func (b *modelBuilder) collapse(f *ssa.Function, entry int) Model {
+	model := Model{
+		Workflow:  f.RelString(nil),
+		Truncated: b.truncated,
+	}
+
+	ids := map[int]int{}
+	id := func(v int) int {
+		if existing, ok := ids[v]; ok {
+			return existing
+		}
+		ids[v] = len(model.Nodes)
+
+		node := *b.vertices[v].node
+		node.ID = ids[v]
+		model.Nodes = append(model.Nodes, node)
+		return node.ID
+	}
+
+	queue := []int{entry}
+	visited := map[int]bool{entry: true}
+	for len(queue) > 0 {
+		v := queue[0]
+		queue = queue[1:]
+
+		from := id(v)
+		for _, next := range b.nextCommands(v) {
+			model.Edges = append(model.Edges, Edge{From: from, To: id(next)})
+			if !visited[next] {
+				visited[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	return model
+}
+
+// nextCommands returns the vertices with commands reachable from v without passing another command
+func (b *modelBuilder) nextCommands(v int) []int {
+	var result []int
+	seen := map[int]bool{}
+
+	var visit func(v int)
+	visit = func(v int) {
+		for _, succ := range b.vertices[v].succs {
+			if seen[succ] {
+				continue
+			}
+			seen[succ] = true
+
+			if b.vertices[succ].node != nil {
+				result = append(result, succ)
+			} else {
+				visit(succ)
+			}
+		}
+	}
+	visit(v)
+
+	return result
+}
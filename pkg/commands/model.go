@@ -0,0 +1,287 @@
+package commands
+
+import (
+	"fmt"
+	"github.com/sema/cadencecheck/pkg/analysis"
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/ssa"
+	"strings"
+)
+
+const (
+	// _maxSequences caps the number of command sequences enumerated per function, as the number of control-flow
+	// paths grows exponentially with the number of branches
+	_maxSequences = 64
+
+	_workflowPackage = "go.uber.org/cadence/workflow"
+)
+
+type commandKind struct {
+	name string
+	// targetArg is the index of the argument identifying the target of the command, or -1 if there is none
+	targetArg int
+}
+
+// decisionFunctions maps the functions of the workflow package producing decisions to the commands they produce
+var decisionFunctions = map[string]commandKind{
+	"ExecuteActivity":               {"ExecuteActivity", 1},
+	"ExecuteLocalActivity":          {"ExecuteLocalActivity", 1},
+	"ExecuteChildWorkflow":          {"ExecuteChildWorkflow", 1},
+	"NewTimer":                      {"Timer", -1},
+	"Sleep":                         {"Timer", -1},
+	"SignalExternalWorkflow":        {"SignalExternalWorkflow", 3},
+	"RequestCancelExternalWorkflow": {"RequestCancelExternalWorkflow", -1},
+	"SideEffect":                    {"SideEffect", -1},
+	"MutableSideEffect":             {"MutableSideEffect", 1},
+	"GetVersion":                    {"GetVersion", 1},
+}
+
+// handlerFunctions maps the functions of the workflow package setting up signal and query handling, which do not
+// produce decisions but are part of the workflow's interface, to the commands they are shown as
+var handlerFunctions = map[string]commandKind{
+	"GetSignalChannel": {"GetSignalChannel", 1},
+	"SetQueryHandler":  {"SetQueryHandler", 1},
+}
+
+// Command is a decision produced by a workflow, e.g. scheduling an activity
+type Command struct {
+	Kind string
+	// Target identifies the activity, child workflow, signal, side effect or change ID of the command, if any
+	Target string
+	// Call is the call producing the command
+	Call ssa.CallInstruction
+}
+
+func (c Command) String() string {
+	if c.Target == "" {
+		return c.Kind
+	}
+	return fmt.Sprintf("%s(%s)", c.Kind, c.Target)
+}
+
+// Sequence is the ordered commands produced along one control-flow path of a workflow
+type Sequence []Command
+
+func (s Sequence) String() string {
+	var parts []string
+	for _, c := range s {
+		parts = append(parts, c.String())
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+// Extract returns the distinct sequences of commands produced by f along its control-flow paths, following calls to
+// application functions
+//
+// Loops are assumed to run at most once, callbacks run by the Cadence client (e.g. passed to workflow.Go) are not
+// followed, and at most _maxSequences sequences are returned per function. Returns true if sequences were dropped
+// because of this cap.
+func Extract(f *ssa.Function, callGraph *callgraph.Graph) ([]Sequence, bool) {
+	e := extractor{
+		callGraph: callGraph,
+		kinds:     decisionFunctions,
+		functions: map[*ssa.Function][]Sequence{},
+		active:    map[*ssa.Function]int{},
+	}
+	sequences := e.function(f)
+	return sequences, e.truncated
+}
+
+type extractor struct {
+	callGraph *callgraph.Graph
+	kinds     map[string]commandKind
+	functions map[*ssa.Function][]Sequence
+	// active maps the functions being extracted to their depth in the stack of extracted functions
+	active map[*ssa.Function]int
+	// lowest is the lowest depth of an active function the current extraction hit through recursion
+	lowest    int
+	truncated bool
+}
+
+func (e *extractor) function(f *ssa.Function) []Sequence {
+	if sequences, ok := e.functions[f]; ok {
+		return sequences
+	}
+	if depth, ok := e.active[f]; ok {
+		// recursion, the sequences of f are not known yet
+		if depth < e.lowest {
+			e.lowest = depth
+		}
+		return []Sequence{{}}
+	}
+	if len(f.Blocks) == 0 {
+		return []Sequence{{}} // a function without a body
+	}
+
+	depth := len(e.active)
+	e.active[f] = depth
+	outer := e.lowest
+	e.lowest = depth
+
+	suffixes := map[*ssa.BasicBlock][]Sequence{}
+	sequences := e.block(f.Blocks[0], suffixes)
+	delete(e.active, f)
+
+	// Sequences cut short at a function further up the stack differ when f is reached without that function active
+	if e.lowest >= depth {
+		e.functions[f] = sequences
+	}
+	if outer < e.lowest {
+		e.lowest = outer
+	}
+	return sequences
+}
+
+// block returns the sequences produced from the start of b until the function returns, skipping loop back edges
+func (e *extractor) block(b *ssa.BasicBlock, suffixes map[*ssa.BasicBlock][]Sequence) []Sequence {
+	if sequences, ok := suffixes[b]; ok {
+		return sequences
+	}
+
+	sequences := []Sequence{{}}
+	for _, instr := range b.Instrs {
+		call, ok := instr.(*ssa.Call)
+		if !ok {
+			continue
+		}
+		if alternatives := e.call(call); alternatives != nil {
+			sequences = e.product(sequences, alternatives)
+		}
+	}
+
+	var successors []Sequence
+	for _, succ := range b.Succs {
+		if succ.Dominates(b) {
+			continue // loop back edge
+		}
+		successors = e.merge(successors, e.block(succ, suffixes))
+	}
+	if successors != nil {
+		sequences = e.product(sequences, successors)
+	}
+
+	suffixes[b] = sequences
+	return sequences
+}
+
+// call returns the alternative sequences produced by call, or nil if it does not produce any commands
+func (e *extractor) call(call *ssa.Call) []Sequence {
+	if command, ok := e.command(call); ok {
+		return []Sequence{{command}}
+	}
+
+	var result []Sequence
+	for _, callee := range applicationCallees(call, e.callGraph) {
+		result = e.merge(result, e.function(callee))
+	}
+	return result
+}
+
+// applicationCallees returns the functions of the application which may be called by call
+func applicationCallees(call *ssa.Call, callGraph *callgraph.Graph) []*ssa.Function {
+	node := callGraph.Nodes[call.Parent()]
+	if node == nil {
+		return nil
+	}
+
+	var result []*ssa.Function
+	for _, edge := range node.Out {
+		if edge.Site == call && !analysis.IsLibraryFunction(edge.Callee.Func) {
+			result = append(result, edge.Callee.Func)
+		}
+	}
+	return result
+}
+
+func (e *extractor) command(call *ssa.Call) (Command, bool) {
+	return findCommand(call, e.kinds, e.callGraph)
+}
+
+// FindDecision returns the command produced by call, if it calls a function of the workflow package producing a
+// decision
+func FindDecision(call *ssa.Call, callGraph *callgraph.Graph) (Command, bool) {
+	return findCommand(call, decisionFunctions, callGraph)
+}
+
+// findCommand returns the command produced by call, if it calls one of the given functions of the workflow package
+func findCommand(call *ssa.Call, kinds map[string]commandKind, callGraph *callgraph.Graph) (Command, bool) {
+	signature, err := analysis.CallSignature(call.Common())
+	if err != nil || signature.Package != _workflowPackage || signature.Type != "" {
+		return Command{}, false
+	}
+
+	kind, ok := kinds[signature.Method]
+	if !ok {
+		return Command{}, false
+	}
+
+	command := Command{Kind: kind.name, Call: call}
+	if kind.targetArg >= 0 && kind.targetArg < len(call.Call.Args) {
+		command.Target = target(call.Call.Args[kind.targetArg], callGraph)
+	}
+	return command, true
+}
+
+// target describes a constant string, or the functions a value may hold, independent of the package it is built in
+func target(value ssa.Value, callGraph *callgraph.Graph) string {
+	if iface, ok := value.(*ssa.MakeInterface); ok {
+		value = iface.X // activities and workflows may be passed by name as interface{}
+	}
+
+	if s, ok := analysis.ConstString(value); ok {
+		return fmt.Sprintf("%q", s)
+	}
+
+	fns, err := analysis.ResolveFunctions(value, callGraph, map[ssa.Value]bool{})
+	if err != nil || len(fns) == 0 {
+		return "?"
+	}
+
+	var names []string
+	for _, f := range fns {
+		names = append(names, RelativeName(f))
+	}
+	return strings.Join(names, "|")
+}
+
+// RelativeName returns the name of f relative to its package, which identifies it across revisions of the package
+func RelativeName(f *ssa.Function) string {
+	if f.Pkg == nil {
+		return f.String()
+	}
+	return f.RelString(f.Pkg.Pkg)
+}
+
+// product returns all concatenations of a prefix and a suffix
+func (e *extractor) product(prefixes []Sequence, suffixes []Sequence) []Sequence {
+	var result []Sequence
+	for _, prefix := range prefixes {
+		for _, suffix := range suffixes {
+			sequence := make(Sequence, 0, len(prefix)+len(suffix))
+			result = append(result, append(append(sequence, prefix...), suffix...))
+		}
+	}
+	return e.merge(nil, result)
+}
+
+// merge returns the distinct sequences of a and b, capped at _maxSequences
+func (e *extractor) merge(a []Sequence, b []Sequence) []Sequence {
+	seen := map[string]bool{}
+	for _, s := range a {
+		seen[s.String()] = true
+	}
+
+	for _, s := range b {
+		if seen[s.String()] {
+			continue
+		}
+		if len(a) >= _maxSequences {
+			e.truncated = true
+			break
+		}
+
+		seen[s.String()] = true
+		a = append(a, s)
+	}
+	return a
+}
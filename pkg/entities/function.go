@@ -1,6 +1,9 @@
 package entities
 
-import "strings"
+import (
+	"fmt"
+	"strings"
+)
 
 type FunctionPattern struct {
 	Package string
@@ -11,13 +14,53 @@ type FunctionPattern struct {
 func (f *FunctionPattern) String() string {
 	parts := []string{f.Package}
 	if f.Type != "" {
-		parts = append(parts)
+		parts = append(parts, f.Type)
 	}
 	parts = append(parts, f.Method)
 
 	return strings.Join(parts, ".")
 }
 
+// ParseFunctionPattern parses a pattern of the form "<package>.<function>" or "<package>.<type>.<method>", e.g.
+// "crypto/sha256.Sum256" or "encoding/json.Encoder.Encode"
+//
+// The package ends at the first dot of its last path element. Packages whose last path element contains a dot are
+// separated by a colon instead, which can't appear in an import path, e.g. "gopkg.in/yaml.v2:Marshal".
+func ParseFunctionPattern(pattern string) (FunctionPattern, error) {
+	var pkg, name string
+	if idx := strings.Index(pattern, ":"); idx >= 0 {
+		pkg, name = pattern[:idx], pattern[idx+1:]
+	} else {
+		pkgDir, rest := "", pattern
+		if idx := strings.LastIndex(pattern, "/"); idx >= 0 {
+			pkgDir, rest = pattern[:idx+1], pattern[idx+1:]
+		}
+		if idx := strings.Index(rest, "."); idx >= 0 {
+			pkg, name = pkgDir+rest[:idx], rest[idx+1:]
+		}
+	}
+
+	parts := strings.Split(name, ".")
+	for _, part := range parts {
+		if part == "" || pkg == "" {
+			return FunctionPattern{}, fmt.Errorf(
+				"invalid function pattern %q, expected <package>.[<type>.]<function> or <package>:[<type>.]<function>",
+				pattern)
+		}
+	}
+
+	switch len(parts) {
+	case 1:
+		return FunctionPattern{Package: pkg, Method: parts[0]}, nil
+	case 2:
+		return FunctionPattern{Package: pkg, Type: parts[0], Method: parts[1]}, nil
+	default:
+		return FunctionPattern{}, fmt.Errorf(
+			"invalid function pattern %q, expected <package>.[<type>.]<function> or <package>:[<type>.]<function>",
+			pattern)
+	}
+}
+
 func StripVendor(packageName string) string {
 	parts := strings.Split(packageName, "/vendor/")
 	return parts[len(parts)-1]
@@ -0,0 +1,38 @@
+package entities
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestParseFunctionPattern(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		expected FunctionPattern
+	}{
+		{"crypto/sha256.Sum256", FunctionPattern{Package: "crypto/sha256", Method: "Sum256"}},
+		{"encoding/json.Encoder.Encode", FunctionPattern{Package: "encoding/json", Type: "Encoder", Method: "Encode"}},
+		{"sort.Slice", FunctionPattern{Package: "sort", Method: "Slice"}},
+		{"gopkg.in/yaml.v2:Marshal", FunctionPattern{Package: "gopkg.in/yaml.v2", Method: "Marshal"}},
+		{"gopkg.in/yaml.v2:Encoder.Encode", FunctionPattern{Package: "gopkg.in/yaml.v2", Type: "Encoder", Method: "Encode"}},
+		{"github.com/org/repo/pkg:Render", FunctionPattern{Package: "github.com/org/repo/pkg", Method: "Render"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.pattern, func(t *testing.T) {
+			pattern, err := ParseFunctionPattern(test.pattern)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, pattern)
+		})
+	}
+}
+
+func TestParseFunctionPatternInvalid(t *testing.T) {
+	for _, pattern := range []string{"", "sort", "crypto/sha256", "encoding/json.Encoder.Encode.Extra", "a/b:", ":Marshal"} {
+		t.Run(pattern, func(t *testing.T) {
+			_, err := ParseFunctionPattern(pattern)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package entities
+
+import (
+	"fmt"
+	"go/token"
+)
+
+// VersionGuard is a comparison of the version returned by workflow.GetVersion against a constant, which holds for
+// the code it dominates, e.g. the else branch of `if v == workflow.DefaultVersion { ... } else { ... }`
+type VersionGuard struct {
+	ChangeID string
+	Op       token.Token
+	Version  int64
+
+	// MaxSupported is the newest version of the change, if it is a constant
+	MaxSupported    int64
+	HasMaxSupported bool
+}
+
+func (g VersionGuard) String() string {
+	return fmt.Sprintf("%s %s %d", g.ChangeID, g.Op, g.Version)
+}
+
+// HoldsForNewest returns true if the guard holds when running the newest version of the change, or if the newest
+// version is unknown
+func (g VersionGuard) HoldsForNewest() bool {
+	if !g.HasMaxSupported {
+		return true
+	}
+
+	v := g.MaxSupported
+	switch g.Op {
+	case token.EQL:
+		return v == g.Version
+	case token.NEQ:
+		return v != g.Version
+	case token.LSS:
+		return v < g.Version
+	case token.LEQ:
+		return v <= g.Version
+	case token.GTR:
+		return v > g.Version
+	case token.GEQ:
+		return v >= g.Version
+	default:
+		return true
+	}
+}
+
+// HoldForNewest returns true if all guards hold when running the newest version of their change
+func HoldForNewest(guards []VersionGuard) bool {
+	for _, guard := range guards {
+		if !guard.HoldsForNewest() {
+			return false
+		}
+	}
+	return true
+}
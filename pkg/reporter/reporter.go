@@ -2,10 +2,13 @@ package reporter
 
 import (
 	"fmt"
+	"github.com/sema/cadencecheck/pkg/entities"
+	"go/token"
 	"golang.org/x/tools/go/callgraph"
 	"golang.org/x/tools/go/ssa"
 	"io"
 	"log"
+	"strings"
 )
 
 type TerminalReporter struct {
@@ -13,22 +16,38 @@ type TerminalReporter struct {
 	stderr      io.Writer
 	verbose     bool
 	countIssues int
+
+	// newestVersionOnly drops issues found in code which does not run for the newest version of a GetVersion change.
+	// Calls only made for older versions are pruned from the call graph, this drops the remaining issues found in
+	// older version branches within a function.
+	newestVersionOnly bool
 }
 
-func NewTerminalReporter(stdout io.Writer, stderr io.Writer, verbose bool) *TerminalReporter {
+func NewTerminalReporter(stdout io.Writer, stderr io.Writer, verbose bool, newestVersionOnly bool) *TerminalReporter {
 	return &TerminalReporter{
-		stdout:  stdout,
-		stderr:  stderr,
-		verbose: verbose,
+		stdout:            stdout,
+		stderr:            stderr,
+		verbose:           verbose,
+		newestVersionOnly: newestVersionOnly,
 	}
 }
 
+// Verbose returns true if debug information is printed
+func (t *TerminalReporter) Verbose() bool {
+	return t.verbose
+}
+
 func (t *TerminalReporter) Debug(format string, a ...interface{}) {
 	if t.verbose {
 		t.fprintln("DEBUG %s", fmt.Sprintf(format, a...))
 	}
 }
 
+// Info prints information about the checked code which is not an issue
+func (t *TerminalReporter) Info(format string, a ...interface{}) {
+	t.fprintln("INFO %s", fmt.Sprintf(format, a...))
+}
+
 func (t *TerminalReporter) Warning(message string) {
 	t.fprintln("WARNING %s", message)
 }
@@ -41,24 +60,104 @@ func (t *TerminalReporter) EnterWorkflow(relPath string) {
 	t.fprintln("CHECK %s", relPath)
 }
 
-func (t *TerminalReporter) WorkflowIssue(kind string, message string, stackTrace []*callgraph.Edge) {
+// WorkflowIssue reports an issue with a call reached from the workflow through stackTrace, within the given GetVersion
+// guards (see analysis.PathVersionGuards)
+func (t *TerminalReporter) WorkflowIssue(kind string, message string, stackTrace []*callgraph.Edge, guards []entities.VersionGuard) {
+	if !t.issue(kind, message, guards) {
+		return
+	}
+
+	nextIdx := 1
+	for _, edge := range stackTrace {
+		t.fprintln("\t#%3d %s (%s) -->", nextIdx, t.FormatCallSite(edge.Site), edge.Caller.Func.String())
+		nextIdx += 1
+	}
+	last := stackTrace[len(stackTrace)-1]
+	t.fprintln("\t#%3d %s (%s)", nextIdx, t.FormatFunction(last.Callee.Func), last.Callee.Func.String())
+}
+
+// FunctionIssue reports an issue with a function as a whole, e.g. its signature
+func (t *TerminalReporter) FunctionIssue(kind string, message string, f *ssa.Function) {
 	t.countIssues += 1
 
 	t.fprintln("[%s] %s", kind, message)
+	t.fprintln("\t#%3d %s (%s)", 1, t.FormatFunction(f), f.String())
+}
+
+// InstructionIssue reports an issue found at a specific instruction, reached from the workflow through stackTrace,
+// within the given GetVersion guards (see analysis.PathVersionGuards)
+//
+// stackTrace may be empty if the instruction is part of the workflow function itself.
+func (t *TerminalReporter) InstructionIssue(kind string, message string, instr ssa.Instruction, stackTrace []*callgraph.Edge, guards []entities.VersionGuard) {
+	if !t.issue(kind, message, guards) {
+		return
+	}
 
 	nextIdx := 1
 	for _, edge := range stackTrace {
 		t.fprintln("\t#%3d %s (%s) -->", nextIdx, t.FormatCallSite(edge.Site), edge.Caller.Func.String())
 		nextIdx += 1
 	}
-	last := stackTrace[len(stackTrace)-1]
-	t.fprintln("\t#%3d %s (%s)", nextIdx, t.FormatFunction(last.Callee.Func), last.Callee.Func.String())
+	t.fprintln("\t#%3d %s (%s)", nextIdx, t.FormatInstruction(instr), instr.Parent().String())
+}
+
+// issue prints the header of an issue, and the GetVersion guards of the version branch it was found in. Returns false
+// if the issue is dropped, as it is not part of the newest version branch.
+func (t *TerminalReporter) issue(kind string, message string, guards []entities.VersionGuard) bool {
+	var branch []string
+	for _, guard := range guards {
+		if t.newestVersionOnly && !guard.HoldsForNewest() {
+			t.Debug("skipping [%s] %s, not in the newest version branch (%s)", kind, message, guard.String())
+			return false
+		}
+		branch = append(branch, guard.String())
+	}
+
+	t.countIssues += 1
+
+	t.fprintln("[%s] %s", kind, message)
+	if len(branch) > 0 {
+		t.fprintln("\tversion branch: %s", strings.Join(branch, ", "))
+	}
+	return true
 }
 
 func (t *TerminalReporter) ExitWorkflow() {
 
 }
 
+func (t *TerminalReporter) EnterActivity(relPath string) {
+	t.fprintln("CHECK ACTIVITY %s", relPath)
+}
+
+func (t *TerminalReporter) ExitActivity() {
+
+}
+
+func (t *TerminalReporter) EnterQueryHandler(relPath string) {
+	t.fprintln("CHECK QUERY %s", relPath)
+}
+
+func (t *TerminalReporter) ExitQueryHandler() {
+
+}
+
+func (t *TerminalReporter) EnterProgram(pkgName string) {
+	t.fprintln("CHECK PROGRAM %s", pkgName)
+}
+
+func (t *TerminalReporter) ExitProgram() {
+
+}
+
+func (t *TerminalReporter) EnterDiff(relPath string) {
+	t.fprintln("CHECK DIFF %s", relPath)
+}
+
+func (t *TerminalReporter) EnterCompatibility(pkgName string) {
+	t.fprintln("CHECK COMPATIBILITY %s", pkgName)
+}
+
 func (t *TerminalReporter) Footer() {
 	if t.countIssues > 0 {
 		t.fprintln("Found %d issues", t.countIssues)
@@ -79,6 +178,17 @@ func (t *TerminalReporter) FormatCallSite(callSite ssa.CallInstruction) string {
 	return fset.Position(callSite.Pos()).String()
 }
 
+// FormatInstruction formats the position of instr, falling back to the position of its function for instructions
+// without a position
+func (t *TerminalReporter) FormatInstruction(instr ssa.Instruction) string {
+	if instr.Pos() == token.NoPos {
+		return t.FormatFunction(instr.Parent())
+	}
+
+	fset := instr.Parent().Prog.Fset
+	return fset.Position(instr.Pos()).String()
+}
+
 func (t *TerminalReporter) FormatFunction(f *ssa.Function) string {
 	if f == nil {
 		return ""
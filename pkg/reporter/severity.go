@@ -0,0 +1,17 @@
+package reporter
+
+// Severity is the prefix of the kind of a reported issue, e.g. ERROR in ERROR-NON-DETERMINISTIC-CALL
+type Severity string
+
+const (
+	SeverityError   Severity = "ERROR"
+	SeverityWarning Severity = "WARNING"
+
+	// SeverityIgnore disables reporting of an issue
+	SeverityIgnore Severity = "IGNORE"
+)
+
+// Kind returns the kind of an issue with the given name reported at this severity
+func (s Severity) Kind(name string) string {
+	return string(s) + "-" + name
+}
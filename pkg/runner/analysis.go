@@ -2,64 +2,379 @@ package runner
 
 import (
 	"fmt"
+	"github.com/sema/cadencecheck/pkg/analysis"
+	"github.com/sema/cadencecheck/pkg/entities"
+	"go/ast"
+	"go/types"
 	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/callgraph/cha"
 	"golang.org/x/tools/go/callgraph/rta"
+	"golang.org/x/tools/go/callgraph/static"
+	"golang.org/x/tools/go/callgraph/vta"
 	"golang.org/x/tools/go/packages"
 	"golang.org/x/tools/go/ssa"
 	"golang.org/x/tools/go/ssa/ssautil"
+	"golang.org/x/tools/go/types/typeutil"
+	"sort"
+	"strings"
 )
 
-type callGraphConstructor struct {
-	entrypoints []*ssa.Function
-	Graph       *callgraph.Graph
-}
+// CallGraphAlgorithm selects how the call graph is constructed, trading precision for speed
+type CallGraphAlgorithm string
 
-func newCallGraphBuilder() *callGraphConstructor {
-	return &callGraphConstructor{}
-}
+const (
+	// CallGraphCHA resolves interface calls to the methods of every type implementing the interface
+	CallGraphCHA CallGraphAlgorithm = "cha"
+	// CallGraphRTA resolves interface calls to the methods of types reachable from the entrypoints
+	CallGraphRTA CallGraphAlgorithm = "rta"
+	// CallGraphVTA resolves interface calls and function values to the types and functions flowing to the call site
+	CallGraphVTA CallGraphAlgorithm = "vta"
+	// CallGraphStatic only contains calls to statically known functions
+	CallGraphStatic CallGraphAlgorithm = "static"
+)
 
-func (c *callGraphConstructor) update() {
-	rtares := rta.Analyze(c.entrypoints, true)
-	c.Graph = rtares.CallGraph
+// CallGraphAlgorithms are the supported call graph algorithms
+var CallGraphAlgorithms = []CallGraphAlgorithm{CallGraphCHA, CallGraphRTA, CallGraphVTA, CallGraphStatic}
+
+// rootRegistration is a function registering argument argIdx to be called through reflection, e.g. by Fx or Cadence
+type rootRegistration struct {
+	pattern entities.FunctionPattern
+	argIdx  int
 }
 
-func (c *callGraphConstructor) AddEntrypoints(entrypoints []*ssa.Function) {
-	c.entrypoints = append(c.entrypoints, entrypoints...)
-	c.update()
+func rootRegistrations() []rootRegistration {
+	var result []rootRegistration
+	for _, pattern := range _fxProviderPatterns {
+		result = append(result, rootRegistration{pattern: pattern, argIdx: 0})
+	}
+	for _, pattern := range append(_cadenceRegisterPatterns, _cadenceActivityRegisterPatterns...) {
+		result = append(result, rootRegistration{pattern: pattern, argIdx: 0})
+	}
+	for _, pattern := range _cadenceQueryHandlerPatterns {
+		result = append(result, rootRegistration{pattern: pattern, argIdx: 2})
+	}
+	return result
 }
 
-func (c *callGraphConstructor) AddPackageMains(pkgs []*ssa.Package) {
+// mainFunctions returns the main and init functions of all main packages
+func mainFunctions(pkgs []*ssa.Package) []*ssa.Function {
+	var mains []*ssa.Function
 	for _, mainPkg := range ssautil.MainPackages(pkgs) {
-		c.entrypoints = append(c.entrypoints, mainPkg.Func("main"))
+		mains = append(mains, mainPkg.Func("main"))
 
 		// main package init should recursively call init of imported packages
-		c.entrypoints = append(c.entrypoints, mainPkg.Func("init"))
+		mains = append(mains, mainPkg.Func("init"))
+	}
+	return mains
+}
+
+// libraryPackages returns the packages which are not main packages
+func libraryPackages(pkgs []*ssa.Package) []*ssa.Package {
+	var result []*ssa.Package
+	for _, pkg := range pkgs {
+		if pkg.Pkg.Name() != "main" {
+			result = append(result, pkg)
+		}
 	}
-	c.update()
+	return result
 }
 
-func constructSSA(pkgName string) (*ssa.Program, []*ssa.Package, error) {
-	// Load, parse, and type-check the whole program.
+// libraryFunctions returns the functions through which library packages are entered by the programs importing them:
+// their init functions, and their exported functions and methods. Test functions are exported functions of the test
+// variant of a package.
+func libraryFunctions(prog *ssa.Program, pkgs []*ssa.Package) []*ssa.Function {
+	var result []*ssa.Function
+	for _, pkg := range pkgs {
+		for _, f := range packageFunctions(prog, pkg) {
+			exported := ast.IsExported(f.Name())
+			if recv := f.Signature.Recv(); recv != nil {
+				exported = exported && isExportedType(recv.Type())
+			}
+
+			if f.Name() == "init" || exported {
+				result = append(result, f)
+			}
+		}
+	}
+	return result
+}
+
+// candidateWorkflows returns the exported functions of library packages matching the signature of workflow functions,
+// which may be registered by the programs importing them
+func candidateWorkflows(libraryEntrypoints []*ssa.Function) []*ssa.Function {
+	var result []*ssa.Function
+	for _, f := range libraryEntrypoints {
+		if f.Name() != "init" && analysis.IsWorkflowSignature(f.Signature) {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+// packageFunctions returns the package level functions of pkg, and the methods declared on its named types, ordered by
+// name
+func packageFunctions(prog *ssa.Program, pkg *ssa.Package) []*ssa.Function {
+	var names []string
+	for name := range pkg.Members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var result []*ssa.Function
+	for _, name := range names {
+		switch member := pkg.Members[name].(type) {
+		case *ssa.Function:
+			if member.TypeParams().Len() == 0 {
+				result = append(result, member)
+			}
+		case *ssa.Type:
+			named, ok := member.Type().(*types.Named)
+			if !ok || named.TypeParams().Len() > 0 || types.IsInterface(named) {
+				continue
+			}
+
+			for _, sel := range typeutil.IntuitiveMethodSet(named, &prog.MethodSets) {
+				if f := prog.MethodValue(sel); f != nil && f.Synthetic == "" {
+					result = append(result, f)
+				}
+			}
+		}
+	}
+	return result
+}
+
+// isExportedType returns true if typ, or the type it points to, is an exported named type
+func isExportedType(typ types.Type) bool {
+	if pointer, ok := typ.(*types.Pointer); ok {
+		typ = pointer.Elem()
+	}
+	named, ok := typ.(*types.Named)
+	return ok && named.Obj().Exported()
+}
+
+// findRoots returns the functions to build the call graph from: the given seed functions, and the functions they
+// register with Fx or Cadence, directly or through other registered functions
+//
+// Registrations are discovered on a call graph built once, which must over-approximate the final call graph, e.g.
+// CHA. The reachable functions are extended with each newly registered function, until no new registrations are
+// found. Registrations which can't be resolved are skipped, and reported when discovering the entrypoints.
+func findRoots(prog *ssa.Program, callGraph *callgraph.Graph, seeds []*ssa.Function) []*ssa.Function {
+	type registrationSite struct {
+		site   ssa.CallInstruction
+		argIdx int
+	}
+
+	var sites []registrationSite
+	for _, registration := range rootRegistrations() {
+		registerFunction, err := findRegisterFunctions(prog, registration.pattern)
+		if err != nil || registerFunction == nil {
+			continue
+		}
+		for _, site := range getCallSitesToFunction(registerFunction, callGraph) {
+			sites = append(sites, registrationSite{site: site, argIdx: registration.argIdx})
+		}
+	}
+
+	var roots []*ssa.Function
+	isRoot := map[*ssa.Function]bool{}
+	for _, f := range seeds {
+		if !isRoot[f] {
+			isRoot[f] = true
+			roots = append(roots, f)
+		}
+	}
+
+	reachable := map[*ssa.Function]bool{}
+	processed := map[ssa.CallInstruction]bool{}
+	for added := roots; len(added) > 0; {
+		markReachable(callGraph, added, reachable)
+
+		added = nil
+		for _, s := range sites {
+			if processed[s.site] || !reachable[s.site.Parent()] {
+				continue
+			}
+			processed[s.site] = true
+
+			fns, err := analysis.ResolveFunctions(s.site.Common().Args[s.argIdx], callGraph, map[ssa.Value]bool{})
+			if err != nil {
+				continue
+			}
+			for _, f := range fns {
+				if !isRoot[f] {
+					isRoot[f] = true
+					roots = append(roots, f)
+					added = append(added, f)
+				}
+			}
+		}
+	}
+
+	return roots
+}
+
+// markReachable adds the functions reachable from roots in the call graph to reachable
+func markReachable(callGraph *callgraph.Graph, roots []*ssa.Function, reachable map[*ssa.Function]bool) {
+	queue := append([]*ssa.Function{}, roots...)
+	for len(queue) > 0 {
+		f := queue[0]
+		queue = queue[1:]
+		if reachable[f] {
+			continue
+		}
+		reachable[f] = true
+
+		if node := callGraph.Nodes[f]; node != nil {
+			for _, edge := range node.Out {
+				queue = append(queue, edge.Callee.Func)
+			}
+		}
+	}
+}
+
+// buildCallGraph builds the call graph of prog with the given algorithm. chaGraph is the CHA call graph of prog,
+// which is built if nil.
+func buildCallGraph(
+	algorithm CallGraphAlgorithm,
+	prog *ssa.Program,
+	roots []*ssa.Function,
+	chaGraph *callgraph.Graph,
+) *callgraph.Graph {
+	switch algorithm {
+	case CallGraphCHA, CallGraphVTA:
+		if chaGraph == nil {
+			chaGraph = buildCHACallGraph(prog)
+		}
+		if algorithm == CallGraphVTA {
+			callGraph := vta.CallGraph(ssautil.AllFunctions(prog), chaGraph)
+			sortCallees(callGraph)
+			return callGraph
+		}
+		return chaGraph
+	case CallGraphStatic:
+		return static.CallGraph(prog)
+	default:
+		return rta.Analyze(roots, true).CallGraph
+	}
+}
+
+// buildCHACallGraph builds the CHA call graph of prog
+func buildCHACallGraph(prog *ssa.Program) *callgraph.Graph {
+	callGraph := cha.CallGraph(prog)
+	sortCallees(callGraph)
+	return callGraph
+}
+
+// sortCallees sorts the edges of each call site by callee, placing synthetic callees such as wrappers last. CHA and
+// VTA find the callees of dynamic calls by iterating over maps, which would otherwise make the reported stack traces
+// nondeterministic.
+func sortCallees(callGraph *callgraph.Graph) {
+	for _, node := range callGraph.Nodes {
+		siteIdx := map[ssa.CallInstruction]int{}
+		for _, edge := range node.Out {
+			if _, ok := siteIdx[edge.Site]; !ok {
+				siteIdx[edge.Site] = len(siteIdx)
+			}
+		}
+
+		sort.SliceStable(node.Out, func(i, j int) bool {
+			a, b := node.Out[i], node.Out[j]
+			if siteIdx[a.Site] != siteIdx[b.Site] {
+				return siteIdx[a.Site] < siteIdx[b.Site]
+			}
+			if (a.Callee.Func.Synthetic == "") != (b.Callee.Func.Synthetic == "") {
+				return a.Callee.Func.Synthetic == ""
+			}
+			return a.Callee.Func.String() < b.Callee.Func.String()
+		})
+	}
+}
+
+// countEdges returns the number of edges of the call graph
+func countEdges(callGraph *callgraph.Graph) int {
+	count := 0
+	for _, node := range callGraph.Nodes {
+		count += len(node.Out)
+	}
+	return count
+}
+
+// source is where package patterns are resolved, the zero value being the current directory and environment
+type source struct {
+	// dir is the directory the build system runs in
+	dir string
+	// env is the environment of the build system, e.g. to set GOPATH
+	env []string
+}
+
+// loadPackages loads, parses and type-checks pkgName and its dependencies. Library packages are loaded with their
+// tests, which may be the only code in the package calling its workflows.
+func loadPackages(src source, pkgName string) ([]*packages.Package, error) {
 	cfg := packages.Config{
-		Mode: packages.LoadAllSyntax, // TODO fix - AllPackages does say that this is the expected value
+		Mode:  packages.LoadAllSyntax, // TODO fix - AllPackages does say that this is the expected value
+		Tests: true,
+		Dir:   src.dir,
+		Env:   src.env,
 	}
 	initial, err := packages.Load(&cfg, pkgName)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	for _, pkg := range initial {
 		if pkg.Types == nil || pkg.IllTyped {
-			return nil, nil, fmt.Errorf("package %s is ill typed", pkg.Name)
+			return nil, fmt.Errorf("package %s is ill typed", pkg.Name)
 		}
 	}
 
-	// Create SSA packages for well-typed packages and their dependencies.
-	prog, pkgs := ssautil.AllPackages(initial, ssa.BuilderMode(0))
-	_ = pkgs
+	return withoutTestsOfMainPackages(initial), nil
+}
 
-	// Build SSA code for the whole program.
+// withoutTestsOfMainPackages removes the packages created to test main packages, and the generated test main packages,
+// from the packages loaded with tests. Library packages compiled with their tests replace the library package.
+func withoutTestsOfMainPackages(loaded []*packages.Package) []*packages.Package {
+	mainPackages := map[string]bool{}
+	for _, pkg := range loaded {
+		if pkg.ID == pkg.PkgPath && pkg.Name == "main" {
+			mainPackages[pkg.PkgPath] = true
+		}
+	}
+
+	testedPackages := map[string]bool{}
+	for _, pkg := range loaded {
+		if pkg.ID != pkg.PkgPath {
+			testedPackages[packageUnderTest(pkg)] = true
+		}
+	}
+
+	var result []*packages.Package
+	for _, pkg := range loaded {
+		switch {
+		case strings.HasSuffix(pkg.PkgPath, ".test"):
+			continue // generated test main
+		case pkg.ID != pkg.PkgPath && mainPackages[packageUnderTest(pkg)]:
+			continue
+		case pkg.ID == pkg.PkgPath && !mainPackages[pkg.PkgPath] && testedPackages[pkg.PkgPath]:
+			continue // replaced by the package compiled with its tests
+		}
+		result = append(result, pkg)
+	}
+	return result
+}
+
+// packageUnderTest returns the path of the package tested by a test package, whose ID is e.g. "path [path.test]"
+func packageUnderTest(pkg *packages.Package) string {
+	id := pkg.ID
+	if start := strings.Index(id, " ["); start >= 0 {
+		id = id[start+2:]
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(id, "]"), ".test")
+}
+
+// buildSSA creates and builds the SSA code of the loaded packages and their dependencies
+func buildSSA(initial []*packages.Package) (*ssa.Program, []*ssa.Package) {
+	prog, pkgs := ssautil.AllPackages(initial, ssa.BuilderMode(0))
 	prog.Build()
 
-	return prog, pkgs, nil
+	return prog, pkgs
 }
@@ -1,26 +1,126 @@
 package runner
 
 import (
+	"github.com/sema/cadencecheck/pkg/checks/activityoptions"
+	"github.com/sema/cadencecheck/pkg/checks/argtypes"
+	"github.com/sema/cadencecheck/pkg/checks/contextescape"
+	"github.com/sema/cadencecheck/pkg/checks/continueasnew"
+	"github.com/sema/cadencecheck/pkg/checks/deadlockrisk"
 	"github.com/sema/cadencecheck/pkg/checks/denypackages"
+	"github.com/sema/cadencecheck/pkg/checks/futures"
+	"github.com/sema/cadencecheck/pkg/checks/nonworkflowapis"
+	"github.com/sema/cadencecheck/pkg/checks/optionvalues"
+	"github.com/sema/cadencecheck/pkg/checks/outsideworkflow"
+	"github.com/sema/cadencecheck/pkg/checks/panicrecover"
+	"github.com/sema/cadencecheck/pkg/checks/queryhandler"
+	"github.com/sema/cadencecheck/pkg/checks/selectorreceive"
+	"github.com/sema/cadencecheck/pkg/checks/serializable"
+	"github.com/sema/cadencecheck/pkg/checks/signalnames"
+	"github.com/sema/cadencecheck/pkg/checks/stdcontext"
+	"github.com/sema/cadencecheck/pkg/checks/unusedregistrations"
+	"github.com/sema/cadencecheck/pkg/checks/versioning"
+	"github.com/sema/cadencecheck/pkg/entities"
 	"github.com/sema/cadencecheck/pkg/reporter"
 	"io"
 )
 
 // Run wires together services to create a cadence checker, and runs the checker
-func Run(pkgName string, stdout io.Writer, stderr io.Writer, verbose bool) error {
-	terminalReporter := reporter.NewTerminalReporter(stdout, stderr, verbose)
+func Run(pkgName string, stdout io.Writer, stderr io.Writer, config Config) error {
+	terminalReporter := reporter.NewTerminalReporter(stdout, stderr, config.Verbose, config.NewestVersionOnly)
 
-	checks := []Check{
-		denypackages.New(),
+	serializableCheck := serializable.New()
+
+	heavyFunctions := append([]entities.FunctionPattern{}, deadlockrisk.DefaultHeavyFunctions...)
+	heavyFunctions = append(heavyFunctions, config.HeavyFunctions...)
+
+	checks := Checks{
+		Workflow: []Check{
+			denypackages.New(),
+			argtypes.New(),
+			serializableCheck,
+			activityoptions.New(),
+			optionvalues.New(),
+			futures.New(),
+			selectorreceive.New(),
+			nonworkflowapis.New(),
+			stdcontext.New(),
+			contextescape.New(),
+			versioning.New(),
+			continueasnew.New(),
+			deadlockrisk.New(heavyFunctions),
+		},
+		Activity: []Check{
+			serializableCheck,
+		},
+		QueryHandler: []Check{
+			queryhandler.New(),
+		},
+		Program: []ProgramCheck{
+			outsideworkflow.New(),
+			signalnames.New(),
+		},
+	}
+
+	panicSeverity := config.PanicSeverity
+	if panicSeverity == "" {
+		panicSeverity = reporter.SeverityError
+	}
+	if panicSeverity != reporter.SeverityIgnore {
+		checks.Workflow = append(checks.Workflow, panicrecover.New(panicSeverity))
+	}
+
+	if config.ReportUnused {
+		checks.Program = append(checks.Program, unusedregistrations.New())
 	}
 
-	checker := New(terminalReporter, checks)
+	checker := New(terminalReporter, checks, config)
 	err := checker.Run(pkgName)
 	if err != nil {
-		terminalReporter.Error(err.Error())
+		terminalReporter.Error("%v", err)
+		return nil
+	}
+
+	terminalReporter.Footer()
+	return nil
+}
+
+// RunDiff reports workflows of pkgName which are changed in a way breaking replay, compared to base. base is either
+// a directory holding a copy of the base version of the package, or a git revision of the repository containing it.
+func RunDiff(pkgName string, base string, stdout io.Writer, stderr io.Writer, config Config) error {
+	terminalReporter := reporter.NewTerminalReporter(stdout, stderr, config.Verbose, config.NewestVersionOnly)
+
+	version, cleanup, err := resolveBase(pkgName, base)
+	if err != nil {
+		terminalReporter.Error("%v", err)
+		return nil
+	}
+	defer cleanup()
+
+	checker := New(terminalReporter, Checks{}, config)
+	err = checker.Diff(pkgName, version)
+	if err != nil {
+		terminalReporter.Error("%v", err)
 		return nil
 	}
 
 	terminalReporter.Footer()
 	return nil
 }
+
+// RunModel writes the command state machine of each workflow of pkgName to stdout, in the given format. Warnings and
+// debug information are written to stderr.
+func RunModel(pkgName string, format string, stdout io.Writer, stderr io.Writer, config Config) error {
+	terminalReporter := reporter.NewTerminalReporter(stderr, stderr, config.Verbose, config.NewestVersionOnly)
+
+	checker := New(terminalReporter, Checks{}, config)
+	return checker.Model(pkgName, format, stdout)
+}
+
+// RunTopology writes the graph of workers, workflows and activities of pkgName to stdout, in the given format.
+// Warnings and debug information are written to stderr.
+func RunTopology(pkgName string, format string, stdout io.Writer, stderr io.Writer, config Config) error {
+	terminalReporter := reporter.NewTerminalReporter(stderr, stderr, config.Verbose, config.NewestVersionOnly)
+
+	checker := New(terminalReporter, Checks{}, config)
+	return checker.Topology(pkgName, format, stdout)
+}
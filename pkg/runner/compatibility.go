@@ -0,0 +1,230 @@
+package runner
+
+import (
+	"fmt"
+	"github.com/sema/cadencecheck/pkg/analysis"
+	"github.com/sema/cadencecheck/pkg/commands"
+	"github.com/sema/cadencecheck/pkg/entities"
+	"go/types"
+	"golang.org/x/tools/go/ssa"
+	"sort"
+	"strings"
+)
+
+const (
+	_kindRegistrationRemoved = "ERROR-REGISTRATION-REMOVED"
+	_kindRegistrationRenamed = "ERROR-REGISTRATION-RENAMED"
+	_kindSignatureChanged    = "ERROR-SIGNATURE-CHANGED"
+)
+
+// registration is a workflow or activity as seen by the Cadence server, identified by the name it is registered under
+type registration struct {
+	kind      string
+	name      string
+	signature string
+	f         *ssa.Function
+}
+
+func (r registration) key() string {
+	return r.kind + " " + r.name
+}
+
+// checkCompatibility reports workflows and activities of base which are no longer registered under the same name by
+// head, orphaning in-flight executions, or whose payloads encoded by base can't be decoded by head
+//
+// If base is a copy of the package, its package path is mapped to the path of head when naming its functions and
+// types. Other packages are loaded from the same path by both.
+func (r *Runner) checkCompatibility(base *loadedProgram, head *loadedProgram, copied bool) {
+	paths := map[string]string{}
+	if copied && len(base.packages) == 1 && len(head.packages) == 1 {
+		paths[base.packages[0].Pkg.Path()] = head.packages[0].Pkg.Path()
+	}
+
+	baseRegistrations := registrations(base, paths)
+	headRegistrations := registrations(head, map[string]string{})
+
+	headByKey := map[string]registration{}
+	for _, reg := range headRegistrations {
+		headByKey[reg.key()] = reg
+	}
+	baseByKey := map[string]registration{}
+	for _, reg := range baseRegistrations {
+		baseByKey[reg.key()] = reg
+	}
+
+	for _, reg := range baseRegistrations {
+		if current, ok := headByKey[reg.key()]; ok {
+			if !payloadsCompatible(reg.f, current.f) {
+				r.reporter.FunctionIssue(_kindSignatureChanged, fmt.Sprintf(
+					"%s %s changed its signature from %s to %s, in-flight executions can't decode their payloads",
+					reg.kind, reg.name, reg.signature, current.signature), current.f)
+			}
+			continue
+		}
+
+		if renamed, ok := findRenamed(reg, headRegistrations, baseByKey); ok {
+			r.reporter.FunctionIssue(_kindRegistrationRenamed, fmt.Sprintf(
+				"%s registered as %s is now registered as %s, in-flight executions are orphaned",
+				reg.kind, reg.name, renamed.name), renamed.f)
+			continue
+		}
+
+		r.reporter.FunctionIssue(_kindRegistrationRemoved, fmt.Sprintf(
+			"%s %s is no longer registered, in-flight executions are orphaned", reg.kind, reg.name), reg.f)
+	}
+}
+
+// payloadsCompatible returns true if the JSON encoded parameters of base can be decoded into the parameters of head,
+// and the result of head into the result of base
+func payloadsCompatible(base *ssa.Function, head *ssa.Function) bool {
+	baseParams, headParams := analysis.PayloadParams(base.Signature), analysis.PayloadParams(head.Signature)
+	if len(baseParams) != len(headParams) {
+		return false
+	}
+	for i := range baseParams {
+		if !analysis.JSONCompatible(baseParams[i].Type(), headParams[i].Type()) {
+			return false
+		}
+	}
+
+	baseResult, headResult := analysis.PayloadResult(base.Signature), analysis.PayloadResult(head.Signature)
+	if baseResult == nil || headResult == nil {
+		return baseResult == headResult
+	}
+	return analysis.JSONCompatible(headResult, baseResult)
+}
+
+// findRenamed returns the new registration of the function registered by reg. This is a registration not present in
+// base of either a function with the same name relative to its package, or of the only new function with the same
+// signature.
+func findRenamed(reg registration, head []registration, baseByKey map[string]registration) (registration, bool) {
+	var sameSignature []registration
+	for _, candidate := range head {
+		if _, ok := baseByKey[candidate.key()]; ok || candidate.kind != reg.kind {
+			continue
+		}
+		if commands.RelativeName(candidate.f) == commands.RelativeName(reg.f) {
+			return candidate, true
+		}
+		if candidate.signature == reg.signature {
+			sameSignature = append(sameSignature, candidate)
+		}
+	}
+
+	if len(sameSignature) == 1 {
+		return sameSignature[0], true
+	}
+	return registration{}, false
+}
+
+// registrations returns the registered workflows and activities of a program sorted by name, with package paths
+// mapped according to paths
+func registrations(program *loadedProgram, paths map[string]string) []registration {
+	var result []registration
+	add := func(kind string, fns []*ssa.Function) {
+		for _, f := range fns {
+			name, ok := program.entrypoints.RegisteredNames[f]
+			if !ok {
+				name = registeredName(f, paths)
+			}
+
+			result = append(result, registration{
+				kind:      kind,
+				name:      name,
+				signature: signature(f, paths),
+				f:         f,
+			})
+		}
+	}
+	add("workflow", program.entrypoints.Workflows)
+	add("activity", program.entrypoints.Activities)
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].key() < result[j].key()
+	})
+	return result
+}
+
+// signature returns the parameter and result types of f, ignoring parameter names
+func signature(f *ssa.Function, paths map[string]string) string {
+	qualifier := func(pkg *types.Package) string {
+		return renamePackage(entities.StripVendor(pkg.Path()), paths)
+	}
+
+	var params, results []string
+	for i := 0; i < f.Signature.Params().Len(); i++ {
+		params = append(params, types.TypeString(f.Signature.Params().At(i).Type(), qualifier))
+	}
+	for i := 0; i < f.Signature.Results().Len(); i++ {
+		results = append(results, types.TypeString(f.Signature.Results().At(i).Type(), qualifier))
+	}
+
+	return fmt.Sprintf("(%s) (%s)", strings.Join(params, ", "), strings.Join(results, ", "))
+}
+
+// registeredName returns the name Cadence registers f under unless a name is given explicitly. This is the name the
+// Go runtime reports for f, e.g. main.orderWorkflow for a function of a main package, or
+// example.com/orders.(*Activities).Charge for a method.
+func registeredName(f *ssa.Function, paths map[string]string) string {
+	if parent := f.Parent(); parent != nil {
+		// closures are numbered within the function defining them, e.g. orderWorkflow.func1 and orderWorkflow.func1.1
+		index := f.Name()[strings.LastIndex(f.Name(), "$")+1:]
+		if parent.Parent() == nil {
+			return registeredName(parent, paths) + ".func" + index
+		}
+		return registeredName(parent, paths) + "." + index
+	}
+
+	// the object of a bound method value, e.g. activities.Charge, is the method itself
+	fn, ok := f.Object().(*types.Func)
+	if !ok || fn.Pkg() == nil {
+		return f.String()
+	}
+
+	prefix := "main"
+	if fn.Pkg().Name() != "main" {
+		prefix = symbolPrefix(renamePackage(fn.Pkg().Path(), paths))
+	}
+
+	recv := fn.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return prefix + "." + fn.Name()
+	}
+
+	if ptr, ok := recv.Type().(*types.Pointer); ok {
+		return fmt.Sprintf("%s.(*%s).%s", prefix, typeName(ptr.Elem()), fn.Name())
+	}
+	return fmt.Sprintf("%s.%s.%s", prefix, typeName(recv.Type()), fn.Name())
+}
+
+func typeName(typ types.Type) string {
+	if named, ok := typ.(*types.Named); ok {
+		return named.Obj().Name()
+	}
+	return typ.String()
+}
+
+// symbolPrefix escapes a package path the way the Go linker does in symbol names, i.e. dots in its last element and
+// special characters, e.g. gopkg.in/yaml%2ev2
+func symbolPrefix(path string) string {
+	lastSlash := strings.LastIndex(path, "/")
+
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if c <= ' ' || c == '%' || c == '"' || c >= 0x7F || (c == '.' && i > lastSlash) {
+			fmt.Fprintf(&b, "%%%02x", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// renamePackage returns the path a package path is mapped to, if any
+func renamePackage(path string, paths map[string]string) string {
+	if renamed, ok := paths[path]; ok {
+		return renamed
+	}
+	return path
+}
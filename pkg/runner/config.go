@@ -0,0 +1,28 @@
+package runner
+
+import (
+	"github.com/sema/cadencecheck/pkg/entities"
+	"github.com/sema/cadencecheck/pkg/reporter"
+)
+
+// Config configures the checks being run. The zero value runs all checks with their default settings.
+type Config struct {
+	// Verbose enables printing of debug information
+	Verbose bool
+
+	// PanicSeverity is the severity of panics and recovers in workflows, defaults to reporter.SeverityError
+	PanicSeverity reporter.Severity
+
+	// NewestVersionOnly restricts the analysis to code running for the newest version of each GetVersion change, calls
+	// only made for older versions are not followed
+	NewestVersionOnly bool
+
+	// ReportUnused enables reporting of registered workflows and activities which are never executed by the program
+	ReportUnused bool
+
+	// HeavyFunctions are reported when called from a workflow, in addition to deadlockrisk.DefaultHeavyFunctions
+	HeavyFunctions []entities.FunctionPattern
+
+	// CallGraph is the algorithm used to build the call graph, defaults to CallGraphRTA
+	CallGraph CallGraphAlgorithm
+}
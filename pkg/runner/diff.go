@@ -0,0 +1,298 @@
+package runner
+
+import (
+	"archive/tar"
+	"fmt"
+	"github.com/sema/cadencecheck/pkg/commands"
+	"go/build"
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/ssa"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	_kindNonDeterministicChange = "ERROR-NON-DETERMINISTIC-CHANGE"
+
+	// _baseDirPattern names the temporary directory a git revision is exported to
+	_baseDirPattern = "cadencecheck-base"
+)
+
+// baseVersion is the version of a package to diff against
+type baseVersion struct {
+	// pkgName is the package pattern of the base version, resolved in src
+	pkgName string
+	src     source
+	// copied is set if the base version is a copy of the package at a different package path, which is mapped to the
+	// path of the package when comparing registrations
+	copied bool
+}
+
+// Diff reports workflows of pkgName whose command sequences differ from the workflows of the base version, without
+// the change being guarded by workflow.GetVersion, and workflows and activities whose registration is incompatible
+// with the base version. Replaying the history of a running workflow fails on such changes.
+func (r *Runner) Diff(pkgName string, base baseVersion) error {
+	baseProgram, err := r.loadProgramFrom(base.src, base.pkgName)
+	if err != nil {
+		return err
+	}
+
+	program, err := r.loadProgram(pkgName)
+	if err != nil {
+		return err
+	}
+
+	baseWorkflows := map[string]*ssa.Function{}
+	for _, f := range baseProgram.entrypoints.Workflows {
+		baseWorkflows[commands.RelativeName(f)] = f
+	}
+
+	for _, f := range program.entrypoints.Workflows {
+		r.reporter.EnterDiff(f.RelString(nil))
+
+		base, ok := baseWorkflows[commands.RelativeName(f)]
+		if !ok {
+			r.reporter.Debug("workflow %s is new, skipping", commands.RelativeName(f))
+			continue
+		}
+
+		r.diffWorkflow(base, baseProgram.callGraph, f, program.callGraph)
+	}
+
+	r.reporter.EnterCompatibility(pkgName)
+	r.checkCompatibility(baseProgram, program, base.copied)
+
+	return nil
+}
+
+func (r *Runner) diffWorkflow(base *ssa.Function, baseCallGraph *callgraph.Graph, f *ssa.Function, callGraph *callgraph.Graph) {
+	baseSequences, baseTruncated := commands.Extract(base, baseCallGraph)
+	sequences, truncated := commands.Extract(f, callGraph)
+	if baseTruncated || truncated {
+		// sequences beyond the cap are dropped arbitrarily, so the remaining sequences can't be compared
+		r.reporter.Warning(fmt.Sprintf(
+			"workflow %s has too many command sequences to compare, skipping", f.RelString(nil)))
+		return
+	}
+
+	changes := commands.Compare(baseSequences, sequences)
+
+	for _, s := range changes.Added {
+		r.reporter.FunctionIssue(_kindNonDeterministicChange, fmt.Sprintf(
+			"workflow produces the new command sequence %s without a new workflow.GetVersion guard", s), f)
+	}
+	for _, s := range changes.Removed {
+		r.reporter.FunctionIssue(_kindNonDeterministicChange, fmt.Sprintf(
+			"workflow no longer produces the command sequence %s without a new workflow.GetVersion guard", s), f)
+	}
+}
+
+// resolveBase returns the base version of pkgName, which is either a directory holding a copy of the package, or a
+// git revision of the repository containing it. The returned function removes any temporary files.
+//
+// Only the package itself is copied to a directory, so the packages it imports are diffed against their current
+// version. A git revision is diffed as a whole, by exporting the repository at that revision.
+func resolveBase(pkgName string, base string) (baseVersion, func(), error) {
+	if info, err := os.Stat(base); err == nil && info.IsDir() {
+		dir, err := filepath.Abs(base)
+		if err != nil {
+			return baseVersion{}, nil, err
+		}
+		return baseVersion{pkgName: dir, copied: true}, func() {}, nil
+	}
+
+	pkg, err := loadPackageInfo(pkgName)
+	if err != nil {
+		return baseVersion{}, nil, err
+	}
+
+	repoRoot, err := git(filepath.Dir(pkg.GoFiles[0]), "rev-parse", "--show-toplevel")
+	if err != nil {
+		return baseVersion{}, nil, err
+	}
+
+	tempDir, err := ioutil.TempDir("", _baseDirPattern)
+	if err != nil {
+		return baseVersion{}, nil, err
+	}
+	cleanup := func() {
+		_ = os.RemoveAll(tempDir)
+	}
+
+	src, err := exportRevision(pkg, strings.TrimSpace(repoRoot), base, tempDir)
+	if err != nil {
+		cleanup()
+		return baseVersion{}, nil, err
+	}
+
+	return baseVersion{pkgName: pkg.PkgPath, src: src}, cleanup, nil
+}
+
+// loadPackageInfo returns the package path, source files and module of pkgName
+func loadPackageInfo(pkgName string) (*packages.Package, error) {
+	mode := packages.NeedName | packages.NeedFiles | packages.NeedModule
+	pkgs, err := packages.Load(&packages.Config{Mode: mode}, pkgName)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 || len(pkgs[0].GoFiles) == 0 {
+		return nil, fmt.Errorf("could not find source files of package %s", pkgName)
+	}
+
+	return pkgs[0], nil
+}
+
+// exportRevision writes the repository at repoRoot, as of the given git revision, to targetDir. Returns the source
+// which resolves the package path of pkg to the exported revision, and the packages outside of the repository to
+// their current version.
+func exportRevision(pkg *packages.Package, repoRoot string, revision string, targetDir string) (source, error) {
+	if pkg.Module != nil {
+		// the module is loaded from the exported directory instead
+		moduleDir, err := relativePath(repoRoot, pkg.Module.Dir)
+		if err != nil {
+			return source{}, err
+		}
+		if err := extractRevision(repoRoot, revision, targetDir); err != nil {
+			return source{}, err
+		}
+		return source{dir: filepath.Join(targetDir, moduleDir)}, nil
+	}
+
+	// the exported repository is placed in a GOPATH entry taking precedence over the current GOPATH
+	srcDir, importPath, err := gopathImportPath(repoRoot)
+	if err != nil {
+		return source{}, err
+	}
+
+	exportDir := filepath.Join(targetDir, "src", importPath)
+	if err := os.MkdirAll(exportDir, 0755); err != nil {
+		return source{}, err
+	}
+	if err := extractRevision(repoRoot, revision, exportDir); err != nil {
+		return source{}, err
+	}
+	if err := linkVendorDirs(srcDir, repoRoot, filepath.Join(targetDir, "src")); err != nil {
+		return source{}, err
+	}
+
+	gopath := targetDir + string(filepath.ListSeparator) + build.Default.GOPATH
+	return source{env: append(os.Environ(), "GOPATH="+gopath)}, nil
+}
+
+// gopathImportPath returns the src directory of the GOPATH entry containing dir, and the import path of dir
+func gopathImportPath(dir string) (string, string, error) {
+	for _, gopath := range filepath.SplitList(build.Default.GOPATH) {
+		srcDir := filepath.Join(gopath, "src")
+		if importPath, err := relativePath(srcDir, dir); err == nil {
+			return srcDir, importPath, nil
+		}
+	}
+	return "", "", fmt.Errorf("repository %s is neither a module nor in GOPATH", dir)
+}
+
+// relativePath returns the path of target relative to base, or an error if target is not within base
+func relativePath(base string, target string) (string, error) {
+	base, err := filepath.EvalSymlinks(base)
+	if err != nil {
+		return "", err
+	}
+	target, err = filepath.EvalSymlinks(target)
+	if err != nil {
+		return "", err
+	}
+
+	rel, err := filepath.Rel(base, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is not within %s", target, base)
+	}
+	return rel, nil
+}
+
+// linkVendorDirs links the vendor directories of repoRoot and its parents within srcDir into targetSrcDir, unless the
+// exported revision has its own. Vendored dependencies which are not committed then resolve the same way as for the
+// current version.
+func linkVendorDirs(srcDir string, repoRoot string, targetSrcDir string) error {
+	for dir := repoRoot; ; dir = filepath.Dir(dir) {
+		importPath, err := relativePath(srcDir, dir)
+		if err != nil || importPath == "." {
+			return nil
+		}
+
+		vendorDir := filepath.Join(dir, "vendor")
+		targetVendorDir := filepath.Join(targetSrcDir, importPath, "vendor")
+		if info, err := os.Stat(vendorDir); err != nil || !info.IsDir() {
+			continue
+		}
+		if _, err := os.Lstat(targetVendorDir); err == nil {
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(targetVendorDir), 0755); err != nil {
+			return err
+		}
+		if err := os.Symlink(vendorDir, targetVendorDir); err != nil {
+			return err
+		}
+	}
+}
+
+// extractRevision writes the files of the repository at repoRoot, as of the given git revision, to targetDir
+func extractRevision(repoRoot string, revision string, targetDir string) error {
+	archive, err := git(repoRoot, "archive", "--format=tar", revision)
+	if err != nil {
+		return err
+	}
+
+	reader := tar.NewReader(strings.NewReader(archive))
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		path := filepath.Join(targetDir, filepath.FromSlash(header.Name))
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(path, 0755)
+		case tar.TypeSymlink:
+			err = os.Symlink(header.Linkname, path)
+		case tar.TypeReg:
+			err = writeFile(path, reader, os.FileMode(header.Mode).Perm())
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func writeFile(path string, r io.Reader, perm os.FileMode) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(file, r)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s failed: %s", strings.Join(args, " "), err)
+	}
+	return string(output), nil
+}
@@ -0,0 +1,38 @@
+package runner
+
+import (
+	"fmt"
+	"github.com/sema/cadencecheck/pkg/commands"
+	"io"
+)
+
+const (
+	ModelFormatJSON = "json"
+	ModelFormatDOT  = "dot"
+)
+
+// Model writes the command state machine of each workflow of pkgName to w, in the given format
+func (r *Runner) Model(pkgName string, format string, w io.Writer) error {
+	program, err := r.loadProgram(pkgName)
+	if err != nil {
+		return err
+	}
+
+	var models []commands.Model
+	for _, f := range program.entrypoints.Workflows {
+		model := commands.BuildModel(f, program.callGraph)
+		if model.Truncated {
+			r.reporter.Warning(fmt.Sprintf("command model of workflow %s is truncated", f.RelString(nil)))
+		}
+		models = append(models, model)
+	}
+
+	switch format {
+	case ModelFormatJSON:
+		return commands.WriteJSON(w, models)
+	case ModelFormatDOT:
+		return commands.WriteDOT(w, models)
+	default:
+		return fmt.Errorf("unknown model format %s", format)
+	}
+}
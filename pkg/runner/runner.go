@@ -1,10 +1,13 @@
 package runner
 
 import (
+	"fmt"
+	"github.com/sema/cadencecheck/pkg/analysis"
 	"github.com/sema/cadencecheck/pkg/entities"
 	"github.com/sema/cadencecheck/pkg/reporter"
 	"golang.org/x/tools/go/callgraph"
 	"golang.org/x/tools/go/ssa"
+	"time"
 )
 
 var (
@@ -21,6 +24,43 @@ var (
 		},
 	}
 
+	_cadenceActivityRegisterPatterns = []entities.FunctionPattern{
+		{
+			Package: "go.uber.org/cadence/activity",
+			Type:    "",
+			Method:  "Register",
+		},
+		{
+			Package: "go.uber.org/cadence/activity",
+			Type:    "",
+			Method:  "RegisterWithOptions",
+		},
+	}
+
+	// Query handlers are registered from within workflows, as the third argument to SetQueryHandler
+	_cadenceQueryHandlerPatterns = []entities.FunctionPattern{
+		{
+			Package: "go.uber.org/cadence/workflow",
+			Type:    "",
+			Method:  "SetQueryHandler",
+		},
+	}
+
+	// Workflows and activities registered with options may be registered under a name set in the options, passed as
+	// the second argument
+	_cadenceRegisterWithOptionsPatterns = []entities.FunctionPattern{
+		{
+			Package: "go.uber.org/cadence/workflow",
+			Type:    "",
+			Method:  "RegisterWithOptions",
+		},
+		{
+			Package: "go.uber.org/cadence/activity",
+			Type:    "",
+			Method:  "RegisterWithOptions",
+		},
+	}
+
 	_fxProviderPatterns = []entities.FunctionPattern{
 		{
 			Package: "go.uber.org/fx",
@@ -34,70 +74,252 @@ type Check interface {
 	Check(f *ssa.Function, callGraph *callgraph.Graph, reporter *reporter.TerminalReporter) error
 }
 
+// ProgramCheck is run once against the whole program, rather than against each registered function
+type ProgramCheck interface {
+	CheckProgram(entrypoints analysis.Entrypoints, callGraph *callgraph.Graph, reporter *reporter.TerminalReporter) error
+}
+
+// Checks groups checks by the kind of registered function they are run against
+type Checks struct {
+	Workflow     []Check
+	Activity     []Check
+	QueryHandler []Check
+	Program      []ProgramCheck
+}
+
 type Runner struct {
-	reporter *reporter.TerminalReporter
-	checks   []Check
+	reporter           *reporter.TerminalReporter
+	checks             Checks
+	callGraphAlgorithm CallGraphAlgorithm
+	newestVersionOnly  bool
 }
 
-func New(reporter *reporter.TerminalReporter, checks []Check) *Runner {
+// New creates a runner, building call graphs with the algorithm of the config. Defaults to CallGraphRTA if empty.
+func New(reporter *reporter.TerminalReporter, checks Checks, config Config) *Runner {
+	callGraphAlgorithm := config.CallGraph
+	if callGraphAlgorithm == "" {
+		callGraphAlgorithm = CallGraphRTA
+	}
+
 	return &Runner{
-		reporter: reporter,
-		checks:   checks,
+		reporter:           reporter,
+		checks:             checks,
+		callGraphAlgorithm: callGraphAlgorithm,
+		newestVersionOnly:  config.NewestVersionOnly,
 	}
 }
 
 func (r *Runner) Run(pkgName string) error {
-	prog, pkgs, err := constructSSA(pkgName)
+	program, err := r.loadProgram(pkgName)
 	if err != nil {
 		return err
 	}
+	callGraph, entrypoints := program.callGraph, program.entrypoints
+
+	if r.newestVersionOnly {
+		// entrypoints are discovered before pruning, as registrations do not depend on the version of a workflow
+		analysis.PruneOldVersions(callGraph)
+	}
+
+	for _, f := range append(append([]*ssa.Function{}, entrypoints.Workflows...), entrypoints.CandidateWorkflows...) {
+		r.reporter.EnterWorkflow(f.RelString(nil))
+
+		for _, check := range r.checks.Workflow {
+			if err := check.Check(f, callGraph, r.reporter); err != nil {
+				return err
+			}
+		}
+
+		r.reporter.ExitWorkflow()
+	}
+
+	for _, f := range entrypoints.QueryHandlers {
+		r.reporter.EnterQueryHandler(f.RelString(nil))
+
+		for _, check := range r.checks.QueryHandler {
+			if err := check.Check(f, callGraph, r.reporter); err != nil {
+				return err
+			}
+		}
+
+		r.reporter.ExitQueryHandler()
+	}
+
+	for _, f := range entrypoints.Activities {
+		r.reporter.EnterActivity(f.RelString(nil))
+
+		for _, check := range r.checks.Activity {
+			if err := check.Check(f, callGraph, r.reporter); err != nil {
+				return err
+			}
+		}
+
+		r.reporter.ExitActivity()
+	}
+
+	r.reporter.EnterProgram(pkgName)
+
+	for _, check := range r.checks.Program {
+		if err := check.CheckProgram(entrypoints, callGraph, r.reporter); err != nil {
+			return err
+		}
+	}
 
-	callGraph := newCallGraphBuilder()
-	callGraph.AddPackageMains(pkgs)
+	r.reporter.ExitProgram()
 
+	return nil
+}
+
+// loadedProgram is a program with the functions registered with Cadence discovered
+type loadedProgram struct {
+	prog *ssa.Program
+	// packages are the packages matched by the loaded package pattern
+	packages    []*ssa.Package
+	callGraph   *callgraph.Graph
+	entrypoints analysis.Entrypoints
+}
+
+// loadProgram builds the SSA program and call graph of pkgName, and discovers the functions registered with Cadence
+//
+// The call graph is built once, from the main functions and all functions registered with Fx or Cadence, which are
+// called through reflection and would otherwise be missing from the call graph. Registrations are discovered on a
+// CHA call graph first, see findRoots.
+//
+// Library packages are entered through their init, exported and test functions instead of a main function. If they
+// neither have a main function nor register workflows, their exported functions matching the workflow signature are
+// checked as workflows, as they may be registered by the programs importing them.
+func (r *Runner) loadProgram(pkgName string) (*loadedProgram, error) {
+	return r.loadProgramFrom(source{}, pkgName)
+}
+
+// loadProgramFrom is loadProgram, resolving pkgName in the given source instead of the current directory and
+// environment
+func (r *Runner) loadProgramFrom(src source, pkgName string) (*loadedProgram, error) {
+	start := time.Now()
+	initial, err := loadPackages(src, pkgName)
+	if err != nil {
+		return nil, err
+	}
+	r.timePhase("load packages", start)
+
+	start = time.Now()
+	prog, pkgs := buildSSA(initial)
+	r.timePhase("build SSA", start)
+
+	start = time.Now()
+	chaGraph := buildCHACallGraph(prog)
+	mains := mainFunctions(pkgs)
+	libraries := libraryPackages(pkgs)
+	libraryEntrypoints := libraryFunctions(prog, libraries)
+	var candidateWorkflowFunctions []*ssa.Function
+	if len(mains) == 0 {
+		candidateWorkflowFunctions = candidateWorkflows(libraryEntrypoints)
+	}
+	roots := findRoots(prog, chaGraph, append(append([]*ssa.Function{}, mains...), libraryEntrypoints...))
+	r.timePhase(fmt.Sprintf("discover %d roots", len(roots)), start)
+
+	start = time.Now()
+	callGraph := buildCallGraph(r.callGraphAlgorithm, prog, roots, chaGraph)
+	for _, f := range roots {
+		// Functions only called through reflection may be missing from whole program call graphs
+		callGraph.CreateNode(f)
+	}
+	r.timePhase(fmt.Sprintf("build %s call graph", r.callGraphAlgorithm), start)
+
+	start = time.Now()
 	var fxProviderFunctions []*ssa.Function
 	for _, fxProviderPattern := range _fxProviderPatterns {
-		fns, err := findRegisteredFunctions(r.reporter, prog, callGraph.Graph, fxProviderPattern)
+		fns, err := findRegisteredFunctions(r.reporter, prog, callGraph, fxProviderPattern, 0)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		fxProviderFunctions = append(fxProviderFunctions, fns...)
 	}
-
-	callGraph.AddEntrypoints(fxProviderFunctions)
-
-	/* DEBUG CALL GRAPH
-	for f, _ := range callGraph.Nodes {
-		r.reporter.Debug("call-graph-func %s (%s)", r.reporter.FormatFunction(f), f.Name())
+	rootFunctions := append(append([]*ssa.Function{}, mains...), fxProviderFunctions...)
+	for _, f := range libraryEntrypoints {
+		// Functions taking a workflow.Context are called from workflows of the programs importing the package
+		if params := f.Signature.Params(); params.Len() == 0 || !analysis.IsWorkflowContext(params.At(0).Type()) {
+			rootFunctions = append(rootFunctions, f)
+		}
 	}
-	*/
 
 	var cadenceWorkflowFunctions []*ssa.Function
 	for _, cadenceRegisterPattern := range _cadenceRegisterPatterns {
-		fns, err := findRegisteredFunctions(r.reporter, prog, callGraph.Graph, cadenceRegisterPattern)
+		fns, err := findRegisteredFunctions(r.reporter, prog, callGraph, cadenceRegisterPattern, 0)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		cadenceWorkflowFunctions = append(cadenceWorkflowFunctions, fns...)
 	}
+	if len(cadenceWorkflowFunctions) > 0 {
+		// the package registers its workflows itself, other functions are not meant to be registered as workflows
+		candidateWorkflowFunctions = nil
+	}
 
-	// TODO it should not be necessary to add the Cadence functions as entrypoints to the cc analysis -
-	// however, the call graph has been shown to be missing edges in large programs.
-	callGraph.AddEntrypoints(cadenceWorkflowFunctions)
+	var cadenceActivityFunctions []*ssa.Function
+	for _, cadenceRegisterPattern := range _cadenceActivityRegisterPatterns {
+		fns, err := findRegisteredFunctions(r.reporter, prog, callGraph, cadenceRegisterPattern, 0)
+		if err != nil {
+			return nil, err
+		}
 
-	for _, f := range cadenceWorkflowFunctions {
-		r.reporter.EnterWorkflow(f.RelString(nil))
+		cadenceActivityFunctions = append(cadenceActivityFunctions, fns...)
+	}
 
-		for _, check := range r.checks {
-			if err := check.Check(f, callGraph.Graph, r.reporter); err != nil {
-				return err
-			}
+	var cadenceQueryHandlerFunctions []*ssa.Function
+	for _, cadenceRegisterPattern := range _cadenceQueryHandlerPatterns {
+		fns, err := findRegisteredFunctions(r.reporter, prog, callGraph, cadenceRegisterPattern, 2)
+		if err != nil {
+			return nil, err
 		}
 
-		r.reporter.ExitWorkflow()
+		cadenceQueryHandlerFunctions = append(cadenceQueryHandlerFunctions, fns...)
 	}
 
-	return nil
+	registeredNames := map[*ssa.Function]string{}
+	for _, cadenceRegisterPattern := range _cadenceRegisterWithOptionsPatterns {
+		names := findRegisteredNames(prog, callGraph, cadenceRegisterPattern, 0, 1)
+		for f, name := range names {
+			registeredNames[f] = name
+		}
+	}
+
+	r.timePhase("discover entrypoints", start)
+
+	if r.reporter.Verbose() {
+		r.summarizeCallGraphs(prog, roots)
+	}
+
+	entrypoints := analysis.Entrypoints{
+		Roots:              rootFunctions,
+		Workflows:          cadenceWorkflowFunctions,
+		CandidateWorkflows: candidateWorkflowFunctions,
+		Activities:         cadenceActivityFunctions,
+		QueryHandlers:      cadenceQueryHandlerFunctions,
+		RegisteredNames:    registeredNames,
+	}
+
+	return &loadedProgram{
+		prog:        prog,
+		packages:    pkgs,
+		callGraph:   callGraph,
+		entrypoints: entrypoints,
+	}, nil
+}
+
+// summarizeCallGraphs prints the size of the call graph built by each algorithm, and the time taken to build it
+func (r *Runner) summarizeCallGraphs(prog *ssa.Program, roots []*ssa.Function) {
+	for _, algorithm := range CallGraphAlgorithms {
+		start := time.Now()
+		callGraph := buildCallGraph(algorithm, prog, roots, nil)
+		r.reporter.Debug("call graph %s: %d nodes, %d edges, built in %s",
+			algorithm, len(callGraph.Nodes), countEdges(callGraph), time.Since(start).Round(time.Millisecond))
+	}
+}
+
+// timePhase prints the time taken by a phase of loading a program, which started at start
+func (r *Runner) timePhase(phase string, start time.Time) {
+	r.reporter.Debug("phase %s took %s", phase, time.Since(start).Round(time.Millisecond))
 }
@@ -0,0 +1,177 @@
+package runner
+
+import (
+	"fmt"
+	"github.com/sema/cadencecheck/pkg/analysis"
+	"github.com/sema/cadencecheck/pkg/entities"
+	"github.com/sema/cadencecheck/pkg/reporter"
+	"github.com/sema/cadencecheck/pkg/topology"
+	"golang.org/x/tools/go/ssa"
+	"io"
+)
+
+const (
+	TopologyFormatDOT     = "dot"
+	TopologyFormatMermaid = "mermaid"
+	TopologyFormatJSON    = "json"
+)
+
+var (
+	_cadenceActivityExecutePatterns = []entities.FunctionPattern{
+		{
+			Package: "go.uber.org/cadence/workflow",
+			Type:    "",
+			Method:  "ExecuteActivity",
+		},
+		{
+			Package: "go.uber.org/cadence/workflow",
+			Type:    "",
+			Method:  "ExecuteLocalActivity",
+		},
+	}
+
+	_cadenceChildWorkflowExecutePatterns = []entities.FunctionPattern{
+		{
+			Package: "go.uber.org/cadence/workflow",
+			Type:    "",
+			Method:  "ExecuteChildWorkflow",
+		},
+	}
+)
+
+// Topology writes the graph of workers, workflows and activities of pkgName to w, in the given format
+func (r *Runner) Topology(pkgName string, format string, w io.Writer) error {
+	program, err := r.loadProgram(pkgName)
+	if err != nil {
+		return err
+	}
+
+	t := topologyBuilder{
+		reporter: r.reporter,
+		program:  program,
+		graph:    topology.New(),
+		names:    map[string]*ssa.Function{},
+	}
+	for f, name := range program.entrypoints.RegisteredNames {
+		t.names[name] = f
+	}
+
+	if err := t.addWorkers(); err != nil {
+		return err
+	}
+	for _, f := range program.entrypoints.Workflows {
+		t.addExecutions(f, _cadenceActivityExecutePatterns, topology.KindActivity, topology.EdgeExecutesActivity)
+		t.addExecutions(f, _cadenceChildWorkflowExecutePatterns, topology.KindWorkflow, topology.EdgeExecutesChild)
+	}
+
+	t.graph.Sort()
+	t.graph.MarkCycles()
+
+	switch format {
+	case TopologyFormatDOT:
+		return t.graph.WriteDOT(w)
+	case TopologyFormatMermaid:
+		return t.graph.WriteMermaid(w)
+	case TopologyFormatJSON:
+		return t.graph.WriteJSON(w)
+	default:
+		return fmt.Errorf("unknown topology format %s", format)
+	}
+}
+
+type topologyBuilder struct {
+	reporter *reporter.TerminalReporter
+	program  *loadedProgram
+	graph    *topology.Graph
+	// names maps names set by registration options to the registered functions
+	names map[string]*ssa.Function
+}
+
+// addWorkers connects the root functions of the program to the workflows registered from code reachable from them
+func (t *topologyBuilder) addWorkers() error {
+	roots := map[*ssa.Function]bool{}
+	for _, f := range t.program.entrypoints.Roots {
+		roots[f] = true
+	}
+
+	for _, pattern := range _cadenceRegisterPatterns {
+		registerFunction, err := findRegisterFunctions(t.program.prog, pattern)
+		if err != nil {
+			return err
+		}
+		if registerFunction == nil {
+			continue
+		}
+
+		for _, callSite := range getCallSitesToFunction(registerFunction, t.program.callGraph) {
+			fns, err := analysis.ResolveFunctions(callSite.Common().Args[0], t.program.callGraph, map[ssa.Value]bool{})
+			if err != nil {
+				continue // reported when discovering workflows
+			}
+
+			workers := analysis.FindRoots(t.program.callGraph.Nodes[callSite.Parent()], func(f *ssa.Function) bool {
+				return roots[f]
+			})
+			for _, worker := range workers {
+				for _, f := range fns {
+					t.graph.AddEdge(
+						t.graph.AddNode(topology.KindWorker, worker.RelString(nil)),
+						t.graph.AddNode(topology.KindWorkflow, t.name(f)),
+						topology.EdgeRegisters)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// addExecutions connects workflow f to the activities or child workflows it executes through calls matching patterns
+func (t *topologyBuilder) addExecutions(f *ssa.Function, patterns []entities.FunctionPattern, kind string, edgeKind string) {
+	from := t.graph.AddNode(topology.KindWorkflow, t.name(f))
+
+	root := t.program.callGraph.Nodes[f]
+	if root == nil {
+		return
+	}
+
+	for _, callSite := range analysis.FindCallSites(root, patterns) {
+		for _, name := range t.targets(callSite.Instruction.Common().Args[1]) {
+			t.graph.AddEdge(from, t.graph.AddNode(kind, name), edgeKind)
+		}
+	}
+}
+
+// targets returns the names of the activities or workflows value may refer to, either by function or by name
+func (t *topologyBuilder) targets(value ssa.Value) []string {
+	if iface, ok := value.(*ssa.MakeInterface); ok {
+		value = iface.X // names are passed as interface{}
+	}
+
+	if name, ok := analysis.ConstString(value); ok {
+		if f, ok := t.names[name]; ok {
+			return []string{t.name(f)}
+		}
+		return []string{name}
+	}
+
+	fns, err := analysis.ResolveFunctions(value, t.program.callGraph, map[ssa.Value]bool{})
+	if err != nil {
+		t.reporter.Debug("unable to infer function executed by %s: %s", value.Name(), err)
+		return nil
+	}
+
+	var result []string
+	for _, f := range fns {
+		result = append(result, t.name(f))
+	}
+	return result
+}
+
+// name returns the name f is registered under
+func (t *topologyBuilder) name(f *ssa.Function) string {
+	if name, ok := t.program.entrypoints.RegisteredNames[f]; ok {
+		return name
+	}
+	return f.RelString(nil)
+}
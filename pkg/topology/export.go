@@ -0,0 +1,84 @@
+package topology
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var dotShapes = map[string]string{
+	KindWorker:   "house",
+	KindWorkflow: "box",
+	KindActivity: "ellipse",
+}
+
+var mermaidShapes = map[string]string{
+	KindWorker:   `[/"%s"\]`,
+	KindWorkflow: `["%s"]`,
+	KindActivity: `("%s")`,
+}
+
+// WriteJSON writes the graph as indented JSON
+func (g *Graph) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(g)
+}
+
+// WriteDOT writes the graph as a Graphviz digraph, with cycles of child workflow executions in red
+func (g *Graph) WriteDOT(w io.Writer) error {
+	var b strings.Builder
+
+	ids := g.shortIDs()
+	b.WriteString("digraph topology {\n")
+	for _, node := range g.Nodes {
+		fmt.Fprintf(&b, "\t%s [label=%q, shape=%s];\n", ids[node.ID], node.Name, dotShapes[node.Kind])
+	}
+	for _, edge := range g.Edges {
+		color := ""
+		if edge.Cycle {
+			color = ", color=red"
+		}
+		fmt.Fprintf(&b, "\t%s -> %s [label=%q%s];\n", ids[edge.From], ids[edge.To], edge.Kind, color)
+	}
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteMermaid writes the graph as a Mermaid flowchart, with cycles of child workflow executions in red
+func (g *Graph) WriteMermaid(w io.Writer) error {
+	var b strings.Builder
+
+	ids := g.shortIDs()
+	b.WriteString("flowchart LR\n")
+	for _, node := range g.Nodes {
+		label := strings.Replace(node.Name, `"`, "#quot;", -1)
+		fmt.Fprintf(&b, "\t%s"+mermaidShapes[node.Kind]+"\n", ids[node.ID], label)
+	}
+
+	var cycles []string
+	for i, edge := range g.Edges {
+		fmt.Fprintf(&b, "\t%s -->|%s| %s\n", ids[edge.From], edge.Kind, ids[edge.To])
+		if edge.Cycle {
+			cycles = append(cycles, fmt.Sprint(i))
+		}
+	}
+	if len(cycles) > 0 {
+		fmt.Fprintf(&b, "\tlinkStyle %s stroke:red\n", strings.Join(cycles, ","))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// shortIDs maps node IDs to identifiers valid in DOT and Mermaid
+func (g *Graph) shortIDs() map[string]string {
+	ids := map[string]string{}
+	for i, node := range g.Nodes {
+		ids[node.ID] = fmt.Sprintf("n%d", i)
+	}
+	return ids
+}
@@ -0,0 +1,157 @@
+package topology
+
+import "sort"
+
+const (
+	KindWorker   = "worker"
+	KindWorkflow = "workflow"
+	KindActivity = "activity"
+
+	EdgeRegisters        = "registers"
+	EdgeExecutesActivity = "executes activity"
+	EdgeExecutesChild    = "executes child workflow"
+)
+
+// Graph connects the workers of a program to the workflows they register, and workflows to the activities and child
+// workflows they execute
+type Graph struct {
+	Nodes []*Node `json:"nodes"`
+	Edges []*Edge `json:"edges"`
+
+	nodes map[string]*Node
+	edges map[Edge]*Edge
+}
+
+type Node struct {
+	ID   string `json:"id"`
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+type Edge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Kind string `json:"kind"`
+	// Cycle is set for child workflow executions which are part of a cycle of child workflow executions
+	Cycle bool `json:"cycle,omitempty"`
+}
+
+func New() *Graph {
+	return &Graph{
+		nodes: map[string]*Node{},
+		edges: map[Edge]*Edge{},
+	}
+}
+
+// AddNode adds a node unless a node of the same kind and name exists, and returns its ID
+func (g *Graph) AddNode(kind string, name string) string {
+	id := kind + ":" + name
+	if _, ok := g.nodes[id]; !ok {
+		node := &Node{ID: id, Kind: kind, Name: name}
+		g.nodes[id] = node
+		g.Nodes = append(g.Nodes, node)
+	}
+	return id
+}
+
+// AddEdge adds an edge unless an identical edge exists
+func (g *Graph) AddEdge(from string, to string, kind string) {
+	key := Edge{From: from, To: to, Kind: kind}
+	if _, ok := g.edges[key]; !ok {
+		edge := key
+		g.edges[key] = &edge
+		g.Edges = append(g.Edges, &edge)
+	}
+}
+
+// Sort orders nodes by kind and name, and edges by their endpoints, for stable output
+func (g *Graph) Sort() {
+	kindOrder := map[string]int{KindWorker: 0, KindWorkflow: 1, KindActivity: 2}
+
+	sort.SliceStable(g.Nodes, func(i, j int) bool {
+		a, b := g.Nodes[i], g.Nodes[j]
+		if a.Kind != b.Kind {
+			return kindOrder[a.Kind] < kindOrder[b.Kind]
+		}
+		return a.Name < b.Name
+	})
+	sort.SliceStable(g.Edges, func(i, j int) bool {
+		a, b := g.Edges[i], g.Edges[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.To != b.To {
+			return a.To < b.To
+		}
+		return a.Kind < b.Kind
+	})
+}
+
+// MarkCycles marks the child workflow executions which are part of a cycle, i.e. whose workflows are in the same
+// strongly connected component of the child workflow graph
+func (g *Graph) MarkCycles() {
+	children := map[string][]string{}
+	for _, edge := range g.Edges {
+		if edge.Kind == EdgeExecutesChild {
+			children[edge.From] = append(children[edge.From], edge.To)
+		}
+	}
+
+	component := stronglyConnectedComponents(g.Nodes, children)
+	for _, edge := range g.Edges {
+		if edge.Kind != EdgeExecutesChild {
+			continue
+		}
+		edge.Cycle = component[edge.From] == component[edge.To]
+	}
+}
+
+// stronglyConnectedComponents assigns each node the index of its strongly connected component, using Tarjan's
+// algorithm
+func stronglyConnectedComponents(nodes []*Node, succs map[string][]string) map[string]int {
+	index := map[string]int{}
+	lowLink := map[string]int{}
+	onStack := map[string]bool{}
+	component := map[string]int{}
+	var stack []string
+	nextIndex, nextComponent := 0, 0
+
+	var visit func(id string)
+	visit = func(id string) {
+		index[id], lowLink[id] = nextIndex, nextIndex
+		nextIndex++
+		stack = append(stack, id)
+		onStack[id] = true
+
+		for _, succ := range succs[id] {
+			if _, ok := index[succ]; !ok {
+				visit(succ)
+				if lowLink[succ] < lowLink[id] {
+					lowLink[id] = lowLink[succ]
+				}
+			} else if onStack[succ] && index[succ] < lowLink[id] {
+				lowLink[id] = index[succ]
+			}
+		}
+
+		if lowLink[id] == index[id] {
+			for {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[top] = false
+				component[top] = nextComponent
+				if top == id {
+					break
+				}
+			}
+			nextComponent++
+		}
+	}
+
+	for _, node := range nodes {
+		if _, ok := index[node.ID]; !ok {
+			visit(node.ID)
+		}
+	}
+	return component
+}